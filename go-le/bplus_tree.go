@@ -4,284 +4,577 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// MinDegree is the default minimum degree (t) for a B+ tree created via
+// NewBPlusTree/NewBPlusTreeWithIntComparator/NewBPlusTreeWithStringComparator:
+// every non-root node holds between MinDegree-1 and 2*MinDegree-1 keys.
 const MinDegree = 3
 
-// Entry represents a key-value pair
-type Entry struct {
-	Key   int
-	Value string
+// KeyComparator orders two keys the way every search path in BPlusTree
+// needs to: negative if a sorts before b, zero if equal, positive if a
+// sorts after b. This is the shape emirpasic/gods/tidwall/btree use for
+// a generic ordered-tree comparator; it's a distinct type from LSM's
+// Comparator interface (see lsm_comparator.go), which orders raw []byte
+// keys for a disk format rather than an arbitrary generic K.
+type KeyComparator[K any] func(a, b K) int
+
+// IntComparator orders keys by ordinary integer comparison.
+func IntComparator(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// StringComparator orders keys lexicographically.
+func StringComparator(a, b string) int {
+	return strings.Compare(a, b)
 }
 
-// BPlusNode represents a node in the B+ tree
-type BPlusNode struct {
+// Entry is a key-value pair stored in a leaf node.
+type Entry[K any, V any] struct {
+	Key   K
+	Value V
+}
+
+// BPlusNode is a node in the B+ tree: leaves hold Entries in sorted key
+// order; internal nodes hold Keys (separators) and Children, with
+// Children[i] holding every key < Keys[i] and Children[i+1] holding
+// every key >= Keys[i] - so Keys[i] always equals the smallest key in
+// the subtree rooted at Children[i+1].
+type BPlusNode[K any, V any] struct {
 	IsLeaf   bool
-	Entries  []Entry      // For leaf nodes
-	Keys     []int        // For internal nodes
-	Children []*BPlusNode // For internal nodes
+	Entries  []Entry[K, V]
+	Keys     []K
+	Children []*BPlusNode[K, V]
+	// Next is the leaf immediately to the right of this one in key
+	// order, nil for the rightmost leaf. Unused on internal nodes. This
+	// is what lets Cursor/RangeQuery walk a range in O(k) after a single
+	// root-to-leaf descent instead of re-descending internal nodes for
+	// every key.
+	Next *BPlusNode[K, V]
+	// Parent is the node's parent, nil for the root, kept up to date by
+	// Insert/splitChild/Delete so sibling lookups and bulk rebalancing
+	// don't need to re-descend from the root. Every place that adopts a
+	// node into a parent it doesn't already belong to cowLoads it first
+	// (see cowLoad), so Parent is never updated on a node still shared
+	// with a Copy() snapshot - the snapshot keeps seeing its own, correct
+	// Parent. The one caveat: until a subtree is itself written through
+	// again, its nodes still carry whatever Parent they had at the last
+	// Copy(), which is accurate for that snapshot but may be one
+	// generation behind the tree that now holds it.
+	Parent *BPlusNode[K, V]
+	// cow is the id of the BPlusTree this node was last written under.
+	// Mutating code must call cowLoad(n) first, which clones n whenever
+	// n.cow doesn't match the tree's own cow id - i.e. whenever n is
+	// still shared with a snapshot taken by Copy().
+	cow uint64
+}
+
+// NumKeys returns the number of keys stored directly in the node -
+// len(Entries) for a leaf, len(Keys) for an internal node.
+func (n *BPlusNode[K, V]) NumKeys() int {
+	if n.IsLeaf {
+		return len(n.Entries)
+	}
+	return len(n.Keys)
 }
 
-// BPlusTree represents the B+ tree
-type BPlusTree struct {
-	Root   *BPlusNode
-	Height int
+// Options configures a BPlusTree's concurrency behavior.
+type Options struct {
+	// NoLocks disables the tree's internal RWMutex, for callers that
+	// already serialize their own access to a tree (or one of its
+	// Copy() snapshots) and want to skip the locking overhead.
+	NoLocks bool
 }
 
-// NewBPlusTree creates a new empty B+ tree
-func NewBPlusTree() *BPlusTree {
-	return &BPlusTree{
-		Root: &BPlusNode{
-			IsLeaf:  true,
-			Entries: make([]Entry, 0),
-		},
-		Height: 1,
+// BPlusTree is a generic B+ tree keyed by any type K with a
+// user-supplied KeyComparator, mirroring the design of emirpasic/gods and
+// tidwall/btree.
+type BPlusTree[K any, V any] struct {
+	Root      *BPlusNode[K, V]
+	Height    int
+	MinDegree int
+	Compare   KeyComparator[K]
+	Options   Options
+
+	mutex sync.RWMutex
+	cow   uint64
+	// tail caches the tree's rightmost leaf so Load can append to it
+	// directly; it's refreshed after every mutation that might move it.
+	tail *BPlusNode[K, V]
+
+	// store and cache are set by OpenBPlusTree for a disk-backed tree;
+	// both are nil for a plain in-memory one (see bplus_tree_pagestore.go).
+	store PageStore
+	cache *nodeCache[K, V]
+}
+
+// cowCounter hands out the process-wide unique ids BPlusTree.Copy uses to
+// tell snapshots apart.
+var cowCounter uint64
+
+// nextCowID returns a fresh id, never returned before, for a tree or one
+// of its Copy() snapshots.
+func nextCowID() uint64 {
+	return atomic.AddUint64(&cowCounter, 1)
+}
+
+// NewBPlusTreeWith creates an empty B+ tree keyed by K, ordered by
+// comparator, with the given minimum degree (the B-tree "t" parameter:
+// every non-root node holds between minDegree-1 and 2*minDegree-1 keys).
+func NewBPlusTreeWith[K any, V any](comparator KeyComparator[K], minDegree int) *BPlusTree[K, V] {
+	if minDegree < 2 {
+		minDegree = 2
 	}
+	cow := nextCowID()
+	return &BPlusTree[K, V]{
+		Root:      &BPlusNode[K, V]{IsLeaf: true, cow: cow},
+		Height:    1,
+		MinDegree: minDegree,
+		Compare:   comparator,
+		cow:       cow,
+	}
+}
+
+// NewBPlusTreeWithIntComparator creates an empty int-keyed B+ tree at
+// the package's default MinDegree.
+func NewBPlusTreeWithIntComparator() *BPlusTree[int, string] {
+	return NewBPlusTreeWith[int, string](IntComparator, MinDegree)
+}
+
+// NewBPlusTreeWithStringComparator creates an empty string-keyed B+
+// tree at the package's default MinDegree.
+func NewBPlusTreeWithStringComparator() *BPlusTree[string, string] {
+	return NewBPlusTreeWith[string, string](StringComparator, MinDegree)
+}
+
+// NewBPlusTree creates a new empty int-keyed, string-valued B+ tree -
+// the convention every DatabaseIndex/DatabaseTable row-offset index in
+// this package is built on.
+func NewBPlusTree() *BPlusTree[int, string] {
+	return NewBPlusTreeWithIntComparator()
+}
+
+// NewLeafNode creates a new empty leaf node.
+func NewLeafNode[K any, V any]() *BPlusNode[K, V] {
+	return &BPlusNode[K, V]{IsLeaf: true}
+}
+
+// NewInternalNode creates a new empty internal node.
+func NewInternalNode[K any, V any]() *BPlusNode[K, V] {
+	return &BPlusNode[K, V]{IsLeaf: false}
 }
 
-// NewLeafNode creates a new leaf node
-func NewLeafNode() *BPlusNode {
-	return &BPlusNode{
-		IsLeaf:  true,
-		Entries: make([]Entry, 0),
+// lock acquires the tree's write lock, unless Options.NoLocks opts out.
+func (t *BPlusTree[K, V]) lock() {
+	if !t.Options.NoLocks {
+		t.mutex.Lock()
 	}
 }
 
-// NewInternalNode creates a new internal node
-func NewInternalNode() *BPlusNode {
-	return &BPlusNode{
-		IsLeaf:   false,
-		Keys:     make([]int, 0),
-		Children: make([]*BPlusNode, 0),
+// unlock releases the tree's write lock, unless Options.NoLocks opts out.
+func (t *BPlusTree[K, V]) unlock() {
+	if !t.Options.NoLocks {
+		t.mutex.Unlock()
 	}
 }
 
-// IsFull checks if a node is full
-func (n *BPlusNode) IsFull() bool {
-	if n.IsLeaf {
-		return len(n.Entries) >= 2*MinDegree-1
+// rlock acquires the tree's read lock, unless Options.NoLocks opts out.
+func (t *BPlusTree[K, V]) rlock() {
+	if !t.Options.NoLocks {
+		t.mutex.RLock()
 	}
-	return len(n.Keys) >= 2*MinDegree-1
 }
 
-// NumKeys returns the number of keys in the node
-func (n *BPlusNode) NumKeys() int {
-	if n.IsLeaf {
-		return len(n.Entries)
+// runlock releases the tree's read lock, unless Options.NoLocks opts out.
+func (t *BPlusTree[K, V]) runlock() {
+	if !t.Options.NoLocks {
+		t.mutex.RUnlock()
 	}
-	return len(n.Keys)
 }
 
-// Insert inserts a key-value pair into the tree
-func (t *BPlusTree) Insert(key int, value string) {
-	if t.Root.IsFull() {
-		oldRoot := t.Root
-		t.Root = NewInternalNode()
-		t.Root.Children = append(t.Root.Children, oldRoot)
-		t.splitChild(0)
-		t.Height++
+// Copy returns a new tree that shares every node with t but carries its
+// own fresh cow id. Because every node currently in the tree still
+// carries t's old id, both t and the returned snapshot will clone a node
+// (via cowLoad) the next time they write through it instead of mutating
+// it in place - so writes to one are invisible to the other, in O(1)
+// time and without copying anything up front.
+func (t *BPlusTree[K, V]) Copy() *BPlusTree[K, V] {
+	t.lock()
+	defer t.unlock()
+
+	snapshot := &BPlusTree[K, V]{
+		Root:      t.Root,
+		Height:    t.Height,
+		MinDegree: t.MinDegree,
+		Compare:   t.Compare,
+		Options:   t.Options,
+		cow:       nextCowID(),
 	}
+	t.cow = nextCowID()
+	t.tail = nil
+	return snapshot
+}
 
-	t.insertNonFull(key, value)
+// cowLoad returns a node safe for the caller to mutate: n itself if it
+// already belongs to t's current cow generation, otherwise a shallow
+// clone of n (stamped with t's cow id) whose slices are copied so
+// appending or reslicing them never touches n or anything it's shared
+// with (e.g. a snapshot returned by Copy).
+func (t *BPlusTree[K, V]) cowLoad(n *BPlusNode[K, V]) *BPlusNode[K, V] {
+	if n.cow == t.cow {
+		return n
+	}
+	clone := &BPlusNode[K, V]{
+		IsLeaf: n.IsLeaf,
+		Next:   n.Next,
+		Parent: n.Parent,
+		cow:    t.cow,
+	}
+	clone.Entries = append([]Entry[K, V](nil), n.Entries...)
+	clone.Keys = append([]K(nil), n.Keys...)
+	// Children are carried over as-is, still pointing at n's old children
+	// and still carrying whatever Parent those children already had. They
+	// must not be touched here: a child can still be shared with a Copy()
+	// snapshot that has every right to keep seeing it parented at n, not
+	// at clone. Only once a child is itself cowLoaded - which every
+	// caller that's about to reparent a child already does - does it
+	// become clone's own, at which point the caller sets its Parent.
+	clone.Children = append([]*BPlusNode[K, V](nil), n.Children...)
+	return clone
 }
 
-// insertNonFull inserts into a node that is not full
-func (t *BPlusTree) insertNonFull(key int, value string) {
+// rightmostLeaf returns the tree's rightmost leaf by following the last
+// child pointer at each level - O(height) pointer hops, no key
+// comparisons.
+func (t *BPlusTree[K, V]) rightmostLeaf() *BPlusNode[K, V] {
 	node := t.Root
-
 	for !node.IsLeaf {
-		// Find the child where the key should go
-		childIdx := 0
-		for i, k := range node.Keys {
-			if key < k {
-				childIdx = i
-				break
-			}
-			childIdx = i + 1
-		}
-
-		// Check if child is full
-		if node.Children[childIdx].IsFull() {
-			t.splitChildInternal(node, childIdx)
-			if key > node.Keys[childIdx] {
-				childIdx++
-			}
-		}
+		node = node.Children[len(node.Children)-1]
+	}
+	return node
+}
 
-		node = node.Children[childIdx]
+// Load is a bulk-loading fast path for ingesting keys in increasing
+// order: as long as key is larger than every key already in the tree and
+// the cached rightmost leaf has room and still belongs to this cow
+// generation, it appends directly into that leaf, skipping the
+// root-to-leaf descent and binary search Insert would otherwise do for
+// every single key. Anything that breaks those conditions - a
+// non-increasing key, a full tail leaf, or a stale cow after Copy - falls
+// back to an ordinary Insert.
+func (t *BPlusTree[K, V]) Load(key K, value V) {
+	t.lock()
+	defer t.unlock()
+
+	if t.tail != nil && t.tail.cow == t.cow && !t.isFull(t.tail) &&
+		(len(t.tail.Entries) == 0 || t.Compare(key, t.tail.Entries[len(t.tail.Entries)-1].Key) > 0) {
+		t.tail.Entries = append(t.tail.Entries, Entry[K, V]{Key: key, Value: value})
+		return
 	}
 
-	// Insert into leaf
-	idx := sort.Search(len(node.Entries), func(i int) bool {
-		return node.Entries[i].Key > key
-	})
+	t.insertLocked(key, value)
+	t.tail = t.rightmostLeaf()
+}
 
-	// Check if key exists
-	if idx > 0 && node.Entries[idx-1].Key == key {
-		node.Entries[idx-1].Value = value
-	} else {
-		// Insert new entry
-		newEntry := Entry{Key: key, Value: value}
-		node.Entries = append(node.Entries[:idx], append([]Entry{newEntry}, node.Entries[idx:]...)...)
-	}
+// isFull reports whether n already holds the maximum 2*MinDegree-1 keys
+// a node may hold before it must be split.
+func (t *BPlusTree[K, V]) isFull(n *BPlusNode[K, V]) bool {
+	return n.NumKeys() >= 2*t.MinDegree-1
 }
 
-// splitChild splits the child of the root
-func (t *BPlusTree) splitChild(childIdx int) {
-	oldChild := t.Root.Children[0]
-	mid := MinDegree - 1
+// childIndex returns the index of node's child that should contain key:
+// the first child whose separator Keys[i] exceeds key, or len(node.Keys)
+// (the rightmost child) if key is not less than every separator.
+func (t *BPlusTree[K, V]) childIndex(node *BPlusNode[K, V], key K) int {
+	return sort.Search(len(node.Keys), func(i int) bool {
+		return t.Compare(node.Keys[i], key) > 0
+	})
+}
 
-	if oldChild.IsLeaf {
-		// Split leaf node
-		newLeaf := NewLeafNode()
+// Insert inserts or updates a key-value pair into the tree.
+func (t *BPlusTree[K, V]) Insert(key K, value V) {
+	t.lock()
+	defer t.unlock()
+	t.insertLocked(key, value)
+	t.tail = t.rightmostLeaf()
+}
 
-		// Copy second half to new leaf
-		newLeaf.Entries = make([]Entry, len(oldChild.Entries)-mid)
-		copy(newLeaf.Entries, oldChild.Entries[mid:])
+// insertLocked does the work of Insert, assuming the caller already
+// holds the write lock. It splits the root first if it's already full so
+// insertNonFull can always descend into a node with room to spare.
+func (t *BPlusTree[K, V]) insertLocked(key K, value V) {
+	t.Root = t.cowLoad(t.Root)
+	if t.isFull(t.Root) {
+		oldRoot := t.Root
+		newRoot := &BPlusNode[K, V]{IsLeaf: false, Children: []*BPlusNode[K, V]{oldRoot}, cow: t.cow}
+		oldRoot.Parent = newRoot
+		t.Root = newRoot
+		t.splitChild(newRoot, 0)
+		t.Height++
+	}
+	t.insertNonFull(t.Root, key, value)
+}
 
-		// Keep first half in old leaf
-		oldChild.Entries = oldChild.Entries[:mid]
+// insertNonFull inserts key/value starting at node, which must not
+// already be full and must already belong to the tree's current cow
+// generation. Internal nodes cowLoad and pre-emptively split a full
+// child before descending into it, the standard single-pass B-tree
+// insert.
+func (t *BPlusTree[K, V]) insertNonFull(node *BPlusNode[K, V], key K, value V) {
+	if node.IsLeaf {
+		idx := sort.Search(len(node.Entries), func(i int) bool {
+			return t.Compare(node.Entries[i].Key, key) > 0
+		})
+		if idx > 0 && t.Compare(node.Entries[idx-1].Key, key) == 0 {
+			node.Entries[idx-1].Value = value
+			return
+		}
+		node.Entries = append(node.Entries, Entry[K, V]{})
+		copy(node.Entries[idx+1:], node.Entries[idx:])
+		node.Entries[idx] = Entry[K, V]{Key: key, Value: value}
+		return
+	}
 
-		// Add middle key to root
-		t.Root.Keys = append(t.Root.Keys, newLeaf.Entries[0].Key)
-		t.Root.Children = append(t.Root.Children, newLeaf)
+	childIdx := t.childIndex(node, key)
+	child := t.cowLoad(node.Children[childIdx])
+	child.Parent = node
+	node.Children[childIdx] = child
+	if t.isFull(child) {
+		t.splitChild(node, childIdx)
+		if t.Compare(key, node.Keys[childIdx]) >= 0 {
+			childIdx++
+		}
+		child = node.Children[childIdx]
 	}
+	t.insertNonFull(child, key, value)
 }
 
-// splitChildInternal splits a child of an internal node
-func (t *BPlusTree) splitChildInternal(parent *BPlusNode, childIdx int) {
-	child := parent.Children[childIdx]
-	mid := MinDegree - 1
+// splitChild splits parent.Children[idx], which must be full, into two
+// nodes around its middle key, inserting the promoted separator and new
+// sibling into parent. Handles both leaf and internal children - unlike
+// the original int/string tree, which only ever split leaves and so
+// silently overflowed once an internal node filled up.
+func (t *BPlusTree[K, V]) splitChild(parent *BPlusNode[K, V], idx int) {
+	child := parent.Children[idx]
+	mid := t.MinDegree - 1
+
+	if child.IsLeaf {
+		newLeaf := &BPlusNode[K, V]{IsLeaf: true, cow: t.cow, Parent: parent}
+		newLeaf.Entries = append(newLeaf.Entries, child.Entries[mid:]...)
+		child.Entries = child.Entries[:mid:mid]
 
-	if child.IsLeaf && len(child.Entries) > mid {
-		newLeaf := NewLeafNode()
+		newLeaf.Next = child.Next
+		child.Next = newLeaf
 
-		// Copy second half to new leaf
-		newLeaf.Entries = append(newLeaf.Entries, child.Entries[mid:]...)
+		sepKey := newLeaf.Entries[0].Key
+		insertKeyAt(parent, idx, sepKey)
+		insertChildAt(parent, idx+1, newLeaf)
+		return
+	}
 
-		// Keep first half in old child
-		child.Entries = child.Entries[:mid]
+	newInternal := &BPlusNode[K, V]{IsLeaf: false, cow: t.cow, Parent: parent}
+	sepKey := child.Keys[mid]
+	newInternal.Keys = append(newInternal.Keys, child.Keys[mid+1:]...)
+	newInternal.Children = append(newInternal.Children, child.Children[mid+1:]...)
+	for i, moved := range newInternal.Children {
+		moved = t.cowLoad(moved)
+		moved.Parent = newInternal
+		newInternal.Children[i] = moved
+	}
+	child.Keys = child.Keys[:mid:mid]
+	child.Children = child.Children[:mid+1 : mid+1]
 
-		// Insert middle key into parent
-		middleKey := newLeaf.Entries[0].Key
+	insertKeyAt(parent, idx, sepKey)
+	insertChildAt(parent, idx+1, newInternal)
+}
 
-		// Insert key and child into parent
-		parent.Keys = append(parent.Keys, 0)
-		copy(parent.Keys[childIdx+1:], parent.Keys[childIdx:])
-		parent.Keys[childIdx] = middleKey
+// insertKeyAt inserts key into parent.Keys at position idx, shifting
+// later keys right.
+func insertKeyAt[K any, V any](parent *BPlusNode[K, V], idx int, key K) {
+	parent.Keys = append(parent.Keys, key)
+	copy(parent.Keys[idx+1:], parent.Keys[idx:])
+	parent.Keys[idx] = key
+}
 
-		parent.Children = append(parent.Children, nil)
-		copy(parent.Children[childIdx+2:], parent.Children[childIdx+1:])
-		parent.Children[childIdx+1] = newLeaf
-	}
+// insertChildAt inserts child into parent.Children at position idx,
+// shifting later children right.
+func insertChildAt[K any, V any](parent *BPlusNode[K, V], idx int, child *BPlusNode[K, V]) {
+	parent.Children = append(parent.Children, nil)
+	copy(parent.Children[idx+1:], parent.Children[idx:])
+	parent.Children[idx] = child
 }
 
-// Search searches for a value by key
-func (t *BPlusTree) Search(key int) (string, bool) {
+// Search looks up key, returning its value and true if present.
+func (t *BPlusTree[K, V]) Search(key K) (V, bool) {
+	t.rlock()
+	defer t.runlock()
 	return t.searchRecursive(t.Root, key)
 }
 
-// searchRecursive recursively searches for a key
-func (t *BPlusTree) searchRecursive(node *BPlusNode, key int) (string, bool) {
+// searchRecursive descends from node to the leaf that would hold key.
+func (t *BPlusTree[K, V]) searchRecursive(node *BPlusNode[K, V], key K) (V, bool) {
 	if node.IsLeaf {
-		// Linear search in leaf
 		for _, entry := range node.Entries {
-			if entry.Key == key {
+			if t.Compare(entry.Key, key) == 0 {
 				return entry.Value, true
 			}
 		}
-		return "", false
+		var zero V
+		return zero, false
 	}
+	return t.searchRecursive(node.Children[t.childIndex(node, key)], key)
+}
 
-	// Find the child where the key might be
-	childIdx := 0
-	for i, k := range node.Keys {
-		if key < k {
-			childIdx = i
-			break
-		}
-		childIdx = i + 1
+// RangeQuery returns every entry with a key in [start, end], in sorted
+// key order. It descends to the leaf containing start exactly once (via
+// Seek) and then walks the leaf-level Next chain until a key exceeds
+// end, so it costs O(log n + k) rather than the O(m·log n) of
+// recursively re-descending internal nodes per candidate child.
+func (t *BPlusTree[K, V]) RangeQuery(start, end K) []Entry[K, V] {
+	t.rlock()
+	defer t.runlock()
+	var result []Entry[K, V]
+	for c := t.seekLocked(start); c.Valid() && t.Compare(c.Key(), end) <= 0; c.Next() {
+		result = append(result, Entry[K, V]{Key: c.Key(), Value: c.Value()})
 	}
+	return result
+}
 
-	return t.searchRecursive(node.Children[childIdx], key)
+// Cursor is a position within the tree's leaf-level linked list, used to
+// walk entries in sorted key order starting from an arbitrary seek point
+// without re-descending from the root for each step.
+type Cursor[K any, V any] struct {
+	tree *BPlusTree[K, V]
+	node *BPlusNode[K, V]
+	idx  int
 }
 
-// RangeQuery finds all entries in range [start, end]
-func (t *BPlusTree) RangeQuery(start, end int) []Entry {
-	var result []Entry
-	t.rangeQueryRecursive(t.Root, start, end, &result)
-	return result
+// Seek returns a Cursor positioned at the first entry with a key >= key
+// (or an invalid cursor if no such entry exists).
+func (t *BPlusTree[K, V]) Seek(key K) *Cursor[K, V] {
+	t.rlock()
+	defer t.runlock()
+	return t.seekLocked(key)
 }
 
-// rangeQueryRecursive recursively searches for keys in range
-func (t *BPlusTree) rangeQueryRecursive(node *BPlusNode, start, end int, result *[]Entry) {
-	if node.IsLeaf {
-		for _, entry := range node.Entries {
-			if entry.Key >= start && entry.Key <= end {
-				*result = append(*result, entry)
-			}
-		}
-		return
+// seekLocked does the work of Seek, assuming the caller already holds the
+// read (or write) lock.
+func (t *BPlusTree[K, V]) seekLocked(key K) *Cursor[K, V] {
+	node := t.Root
+	for !node.IsLeaf {
+		node = node.Children[t.childIndex(node, key)]
 	}
+	idx := sort.Search(len(node.Entries), func(i int) bool {
+		return t.Compare(node.Entries[i].Key, key) >= 0
+	})
+	return t.cursorAt(node, idx)
+}
 
-	// Search in children
-	for i, key := range node.Keys {
-		if start <= key {
-			t.rangeQueryRecursive(node.Children[i], start, end, result)
-		}
+// SeekFirst returns a Cursor positioned at the tree's smallest entry (or
+// an invalid cursor if the tree is empty).
+func (t *BPlusTree[K, V]) SeekFirst() *Cursor[K, V] {
+	t.rlock()
+	defer t.runlock()
+	node := t.Root
+	for !node.IsLeaf && len(node.Children) > 0 {
+		node = node.Children[0]
 	}
+	return t.cursorAt(node, 0)
+}
 
-	// Check last child
-	if len(node.Children) > len(node.Keys) {
-		lastKey := 0
-		if len(node.Keys) > 0 {
-			lastKey = node.Keys[len(node.Keys)-1]
-		}
-		if end > lastKey {
-			t.rangeQueryRecursive(node.Children[len(node.Keys)], start, end, result)
-		}
+// cursorAt normalizes a (node, idx) position: if idx has run off the end
+// of node's entries, it advances to the next leaf's first entry (and so
+// on) until it lands on a valid entry or falls off the rightmost leaf.
+func (t *BPlusTree[K, V]) cursorAt(node *BPlusNode[K, V], idx int) *Cursor[K, V] {
+	for node != nil && idx >= len(node.Entries) {
+		node = node.Next
+		idx = 0
+	}
+	return &Cursor[K, V]{tree: t, node: node, idx: idx}
+}
+
+// Valid reports whether c is positioned at an entry.
+func (c *Cursor[K, V]) Valid() bool {
+	return c.node != nil && c.idx < len(c.node.Entries)
+}
+
+// Key returns the key of the entry c is positioned at. Only valid to call
+// when c.Valid().
+func (c *Cursor[K, V]) Key() K {
+	return c.node.Entries[c.idx].Key
+}
+
+// Value returns the value of the entry c is positioned at. Only valid to
+// call when c.Valid().
+func (c *Cursor[K, V]) Value() V {
+	return c.node.Entries[c.idx].Value
+}
+
+// Next advances c to the next entry in key order, crossing into the
+// next leaf via its Next pointer if needed, and reports whether the new
+// position is valid.
+func (c *Cursor[K, V]) Next() bool {
+	if !c.Valid() {
+		return false
 	}
+	c.idx++
+	if c.idx >= len(c.node.Entries) {
+		c.node = c.node.Next
+		c.idx = 0
+	}
+	return c.Valid()
 }
 
-// AllKeys returns all keys in sorted order
-func (t *BPlusTree) AllKeys() []int {
-	var keys []int
+// AllKeys returns every key in the tree, in sorted order.
+func (t *BPlusTree[K, V]) AllKeys() []K {
+	t.rlock()
+	defer t.runlock()
+	var keys []K
 	t.collectKeys(t.Root, &keys)
 	return keys
 }
 
-// collectKeys recursively collects all keys
-func (t *BPlusTree) collectKeys(node *BPlusNode, keys *[]int) {
+// collectKeys recursively collects every leaf key under node, in order.
+func (t *BPlusTree[K, V]) collectKeys(node *BPlusNode[K, V], keys *[]K) {
 	if node.IsLeaf {
 		for _, entry := range node.Entries {
 			*keys = append(*keys, entry.Key)
 		}
-	} else {
-		for _, child := range node.Children {
-			t.collectKeys(child, keys)
-		}
+		return
+	}
+	for _, child := range node.Children {
+		t.collectKeys(child, keys)
 	}
 }
 
-// PrintTree prints the tree structure
-func (t *BPlusTree) PrintTree() {
-	fmt.Printf("B+ Tree (min_degree = %d)\n", MinDegree)
+// PrintTree prints the tree structure to stdout.
+func (t *BPlusTree[K, V]) PrintTree() {
+	fmt.Printf("B+ Tree (min_degree = %d)\n", t.MinDegree)
 	fmt.Printf("Height: %d\n", t.Height)
 	t.printNode(t.Root, 0)
 }
 
-// printNode recursively prints a node
-func (t *BPlusTree) printNode(node *BPlusNode, level int) {
+// printNode recursively prints one node and its subtree.
+func (t *BPlusTree[K, V]) printNode(node *BPlusNode[K, V], level int) {
 	indent := strings.Repeat("  ", level)
 
 	if node.IsLeaf {
-		keys := make([]int, len(node.Entries))
+		keys := make([]K, len(node.Entries))
 		for i, entry := range node.Entries {
 			keys[i] = entry.Key
 		}
 		fmt.Printf("%sLeaf: %v\n", indent, keys)
 		for _, entry := range node.Entries {
-			fmt.Printf("%s  %d -> %s\n", indent, entry.Key, entry.Value)
+			fmt.Printf("%s  %v -> %v\n", indent, entry.Key, entry.Value)
 		}
 	} else {
 		fmt.Printf("%sInternal: %v\n", indent, node.Keys)
@@ -291,7 +584,7 @@ func (t *BPlusTree) printNode(node *BPlusNode, level int) {
 	}
 }
 
-// String returns a string representation of the tree
-func (t *BPlusTree) String() string {
+// String returns a string representation of the tree.
+func (t *BPlusTree[K, V]) String() string {
 	return fmt.Sprintf("B+ Tree(height=%d, keys=%v)", t.Height, t.AllKeys())
 }
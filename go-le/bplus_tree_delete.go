@@ -0,0 +1,216 @@
+package main
+
+import "sort"
+
+// Delete removes key from the tree, returning whether it was present.
+// Underflow is fixed on the way back up the recursion (see fixUnderflow):
+// a leaf that drops below MinDegree-1 entries borrows from a sibling if
+// one has spare entries, otherwise merges with a sibling, which can
+// cascade the same borrow-or-merge decision up through every ancestor.
+// The root is collapsed (dropping t.Height) if it ends up an internal
+// node with a single child.
+func (t *BPlusTree[K, V]) Delete(key K) bool {
+	t.lock()
+	defer t.unlock()
+
+	t.Root = t.cowLoad(t.Root)
+	if !t.deleteFrom(t.Root, key) {
+		return false
+	}
+	for !t.Root.IsLeaf && len(t.Root.Children) == 1 {
+		t.Root = t.Root.Children[0]
+		t.Root.Parent = nil
+		t.Height--
+	}
+	t.tail = t.rightmostLeaf()
+	return true
+}
+
+// deleteFrom removes key from the subtree rooted at node, which must
+// already belong to the tree's current cow generation, then - for an
+// internal node - cowLoads whichever child it just recursed into and
+// fixes it up if the removal left it underflowed.
+func (t *BPlusTree[K, V]) deleteFrom(node *BPlusNode[K, V], key K) bool {
+	if node.IsLeaf {
+		idx := sort.Search(len(node.Entries), func(i int) bool {
+			return t.Compare(node.Entries[i].Key, key) >= 0
+		})
+		if idx >= len(node.Entries) || t.Compare(node.Entries[idx].Key, key) != 0 {
+			return false
+		}
+		node.Entries = append(node.Entries[:idx], node.Entries[idx+1:]...)
+		if idx == 0 && len(node.Entries) > 0 {
+			t.fixAncestorSeparator(node, key, node.Entries[0].Key)
+		} else if idx == 0 && node.Next != nil && len(node.Next.Entries) > 0 {
+			// An emptied leaf's min becomes its right neighbor's first key
+			// once fixUnderflow borrows from or merges with that neighbor.
+			t.fixAncestorSeparator(node, key, node.Next.Entries[0].Key)
+		}
+		return true
+	}
+
+	childIdx := t.childIndex(node, key)
+	child := t.cowLoad(node.Children[childIdx])
+	child.Parent = node
+	node.Children[childIdx] = child
+	if !t.deleteFrom(child, key) {
+		return false
+	}
+	t.fixUnderflow(node, childIdx)
+	return true
+}
+
+// fixUnderflow repairs parent.Children[childIdx] if it holds fewer than
+// MinDegree-1 keys: borrowing a single entry/child from whichever
+// neighbor has one to spare (rotating the separator through parent), or
+// - if neither neighbor has a spare - merging child into a neighbor and
+// removing the now-redundant separator from parent.
+func (t *BPlusTree[K, V]) fixUnderflow(parent *BPlusNode[K, V], childIdx int) {
+	child := parent.Children[childIdx]
+	if child.NumKeys() >= t.MinDegree-1 {
+		return
+	}
+
+	if childIdx > 0 && parent.Children[childIdx-1].NumKeys() > t.MinDegree-1 {
+		parent.Children[childIdx-1] = t.cowLoad(parent.Children[childIdx-1])
+		parent.Children[childIdx-1].Parent = parent
+		t.borrowFromLeft(parent, childIdx)
+		return
+	}
+	if childIdx < len(parent.Children)-1 && parent.Children[childIdx+1].NumKeys() > t.MinDegree-1 {
+		parent.Children[childIdx+1] = t.cowLoad(parent.Children[childIdx+1])
+		parent.Children[childIdx+1].Parent = parent
+		t.borrowFromRight(parent, childIdx)
+		return
+	}
+
+	if childIdx > 0 {
+		parent.Children[childIdx-1] = t.cowLoad(parent.Children[childIdx-1])
+		parent.Children[childIdx-1].Parent = parent
+		t.mergeChildren(parent, childIdx-1)
+	} else {
+		parent.Children[childIdx+1] = t.cowLoad(parent.Children[childIdx+1])
+		parent.Children[childIdx+1].Parent = parent
+		t.mergeChildren(parent, childIdx)
+	}
+}
+
+// borrowFromLeft moves the rightmost entry/child of
+// parent.Children[childIdx-1] into parent.Children[childIdx], repairing
+// the separator at parent.Keys[childIdx-1] so it still equals the min
+// key of the right subtree.
+func (t *BPlusTree[K, V]) borrowFromLeft(parent *BPlusNode[K, V], childIdx int) {
+	left := parent.Children[childIdx-1]
+	child := parent.Children[childIdx]
+
+	if child.IsLeaf {
+		moved := left.Entries[len(left.Entries)-1]
+		left.Entries = left.Entries[:len(left.Entries)-1]
+		child.Entries = append([]Entry[K, V]{moved}, child.Entries...)
+		parent.Keys[childIdx-1] = child.Entries[0].Key
+		return
+	}
+
+	movedChild := t.cowLoad(left.Children[len(left.Children)-1])
+	left.Children = left.Children[:len(left.Children)-1]
+	left.Keys = left.Keys[:len(left.Keys)-1]
+
+	newChildSep := t.subtreeMinKey(child.Children[0])
+	child.Keys = append([]K{newChildSep}, child.Keys...)
+	child.Children = append([]*BPlusNode[K, V]{movedChild}, child.Children...)
+	movedChild.Parent = child
+
+	parent.Keys[childIdx-1] = t.subtreeMinKey(movedChild)
+}
+
+// borrowFromRight moves the leftmost entry/child of
+// parent.Children[childIdx+1] into parent.Children[childIdx], repairing
+// the separator at parent.Keys[childIdx] so it still equals the min key
+// of the right subtree.
+func (t *BPlusTree[K, V]) borrowFromRight(parent *BPlusNode[K, V], childIdx int) {
+	child := parent.Children[childIdx]
+	right := parent.Children[childIdx+1]
+
+	if child.IsLeaf {
+		moved := right.Entries[0]
+		right.Entries = right.Entries[1:]
+		child.Entries = append(child.Entries, moved)
+		parent.Keys[childIdx] = right.Entries[0].Key
+		return
+	}
+
+	movedChild := t.cowLoad(right.Children[0])
+	right.Children = right.Children[1:]
+	child.Keys = append(child.Keys, parent.Keys[childIdx])
+	child.Children = append(child.Children, movedChild)
+	movedChild.Parent = child
+
+	parent.Keys[childIdx] = right.Keys[0]
+	right.Keys = right.Keys[1:]
+}
+
+// mergeChildren absorbs parent.Children[leftIdx+1] into
+// parent.Children[leftIdx] and removes the separator at
+// parent.Keys[leftIdx] along with the now-empty right child slot. A leaf
+// merge also splices the right leaf out of the Next chain; an internal
+// merge pulls the separator itself down as the new middle key between
+// the two halves' children, the standard B-tree merge.
+func (t *BPlusTree[K, V]) mergeChildren(parent *BPlusNode[K, V], leftIdx int) {
+	left := parent.Children[leftIdx]
+	right := parent.Children[leftIdx+1]
+
+	if left.IsLeaf {
+		left.Entries = append(left.Entries, right.Entries...)
+		left.Next = right.Next
+	} else {
+		left.Keys = append(left.Keys, parent.Keys[leftIdx])
+		left.Keys = append(left.Keys, right.Keys...)
+		for _, moved := range right.Children {
+			moved = t.cowLoad(moved)
+			moved.Parent = left
+			left.Children = append(left.Children, moved)
+		}
+	}
+
+	parent.Keys = append(parent.Keys[:leftIdx], parent.Keys[leftIdx+1:]...)
+	parent.Children = append(parent.Children[:leftIdx+1], parent.Children[leftIdx+2:]...)
+}
+
+// subtreeMinKey returns the smallest key stored anywhere under node, by
+// following its leftmost-child spine down to a leaf.
+func (t *BPlusTree[K, V]) subtreeMinKey(node *BPlusNode[K, V]) K {
+	for !node.IsLeaf {
+		node = node.Children[0]
+	}
+	return node.Entries[0].Key
+}
+
+// fixAncestorSeparator repairs the one ancestor separator (if any) that
+// was copied from leaf's old minimum key. It walks up via Parent past
+// every ancestor leaf is the leftmost descendant of - those hold no
+// separator for leaf's subtree, since "leftmost child" has nothing to
+// its left - and stops at the first ancestor where leaf's chain is the
+// right-hand side of a separator, rewriting that separator from oldKey
+// to newKey if it still matches. Above that point no separator can equal
+// oldKey, since that would require leaf to be the minimum of an even
+// larger subtree, which only holds while every step down is leftmost.
+func (t *BPlusTree[K, V]) fixAncestorSeparator(leaf *BPlusNode[K, V], oldKey, newKey K) {
+	node := leaf
+	for node.Parent != nil {
+		parent := node.Parent
+		idx := -1
+		for i, c := range parent.Children {
+			if c == node {
+				idx = i
+				break
+			}
+		}
+		if idx > 0 {
+			if t.Compare(parent.Keys[idx-1], oldKey) == 0 {
+				parent.Keys[idx-1] = newKey
+			}
+			return
+		}
+		node = parent
+	}
+}
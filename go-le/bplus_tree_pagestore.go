@@ -0,0 +1,479 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// PageSize is the fixed size, in bytes, every page a PageStore reads or
+// writes must fit within. A node whose encoded form (including every
+// string value in a leaf) exceeds PageSize fails to persist - there's no
+// overflow-page chaining here, unlike a production paged B-tree.
+const PageSize = 4096
+
+// pageMagic tags the start of every encoded node page so a truncated or
+// non-page file is caught on read instead of silently misparsed.
+const pageMagic = 0xB7
+
+// superblockPage holds the tree's root page id and height; node pages
+// are allocated starting at id 1 so they never collide with it.
+const superblockPage = 0
+
+// PageStore is the storage backend a persistent BPlusTree reads and
+// writes pages through. Implementations only move bytes around - node
+// encoding/decoding lives in encodeNodePage/decodeNodePage below.
+type PageStore interface {
+	ReadPage(id uint64) ([]byte, error)
+	WritePage(id uint64, data []byte) error
+	AllocatePage() uint64
+	FreePage(id uint64)
+	Sync() error
+}
+
+// MemoryPageStore is an in-memory PageStore: useful in tests, or for any
+// caller that wants the page/cache machinery without touching disk.
+type MemoryPageStore struct {
+	pages map[uint64][]byte
+	free  []uint64
+	next  uint64
+}
+
+// NewMemoryPageStore creates an empty in-memory PageStore.
+func NewMemoryPageStore() *MemoryPageStore {
+	return &MemoryPageStore{pages: make(map[uint64][]byte), next: 1}
+}
+
+func (m *MemoryPageStore) ReadPage(id uint64) ([]byte, error) {
+	data, ok := m.pages[id]
+	if !ok {
+		return nil, fmt.Errorf("page store: page %d not found", id)
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	return out, nil
+}
+
+func (m *MemoryPageStore) WritePage(id uint64, data []byte) error {
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	m.pages[id] = cp
+	return nil
+}
+
+func (m *MemoryPageStore) AllocatePage() uint64 {
+	if n := len(m.free); n > 0 {
+		id := m.free[n-1]
+		m.free = m.free[:n-1]
+		return id
+	}
+	id := m.next
+	m.next++
+	return id
+}
+
+func (m *MemoryPageStore) FreePage(id uint64) {
+	delete(m.pages, id)
+	m.free = append(m.free, id)
+}
+
+func (m *MemoryPageStore) Sync() error { return nil }
+
+// FilePageStore is a PageStore backed by a fixed-page-size file: page id
+// i lives at byte offset i*PageSize. Freed pages are tracked in an
+// in-memory free list and handed back out by AllocatePage before the
+// file is grown.
+type FilePageStore struct {
+	file *os.File
+	free []uint64
+	next uint64
+}
+
+// OpenFilePageStore opens (creating if necessary) a file-backed
+// PageStore at path, picking up page allocation where a prior session
+// left off based on the file's current size.
+func OpenFilePageStore(path string) (*FilePageStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	next := uint64(info.Size()) / PageSize
+	if next < 1 {
+		next = 1
+	}
+	return &FilePageStore{file: f, next: next}, nil
+}
+
+func (f *FilePageStore) ReadPage(id uint64) ([]byte, error) {
+	buf := make([]byte, PageSize)
+	if _, err := f.file.ReadAt(buf, int64(id)*PageSize); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (f *FilePageStore) WritePage(id uint64, data []byte) error {
+	if len(data) > PageSize {
+		return fmt.Errorf("page store: encoded page (%d bytes) exceeds PageSize %d", len(data), PageSize)
+	}
+	buf := make([]byte, PageSize)
+	copy(buf, data)
+	_, err := f.file.WriteAt(buf, int64(id)*PageSize)
+	return err
+}
+
+func (f *FilePageStore) AllocatePage() uint64 {
+	if n := len(f.free); n > 0 {
+		id := f.free[n-1]
+		f.free = f.free[:n-1]
+		return id
+	}
+	id := f.next
+	f.next++
+	return id
+}
+
+func (f *FilePageStore) FreePage(id uint64) {
+	f.free = append(f.free, id)
+}
+
+func (f *FilePageStore) Sync() error {
+	return f.file.Sync()
+}
+
+// Close closes the underlying file. Callers normally reach this through
+// BPlusTree.Close rather than calling it directly.
+func (f *FilePageStore) Close() error {
+	return f.file.Close()
+}
+
+// nodeCacheSize bounds how many decoded nodes a persistent tree's
+// nodeCache holds. OpenBPlusTree fills the cache with the nodes it loads;
+// page ids are resolved through loadNode's own map, and nothing in this
+// package reads nodes back out of the cache.
+const nodeCacheSize = 256
+
+// nodeCache is a small fixed-capacity LRU cache from page id to the node
+// decoded from it. It's a fast secondary index, not a source of
+// identity: callers that need exactly one in-memory object per page
+// (e.g. a load that's still under construction) must track that
+// themselves rather than relying on the cache never evicting.
+type nodeCache[K any, V any] struct {
+	capacity int
+	order    []uint64
+	nodes    map[uint64]*BPlusNode[K, V]
+}
+
+func newNodeCache[K any, V any](capacity int) *nodeCache[K, V] {
+	return &nodeCache[K, V]{capacity: capacity, nodes: make(map[uint64]*BPlusNode[K, V])}
+}
+
+func (c *nodeCache[K, V]) get(id uint64) (*BPlusNode[K, V], bool) {
+	n, ok := c.nodes[id]
+	if ok {
+		c.touch(id)
+	}
+	return n, ok
+}
+
+func (c *nodeCache[K, V]) put(id uint64, n *BPlusNode[K, V]) {
+	if _, exists := c.nodes[id]; !exists && len(c.nodes) >= c.capacity {
+		c.evictOldest()
+	}
+	c.nodes[id] = n
+	c.touch(id)
+}
+
+func (c *nodeCache[K, V]) touch(id uint64) {
+	for i, existing := range c.order {
+		if existing == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, id)
+}
+
+func (c *nodeCache[K, V]) evictOldest() {
+	if len(c.order) == 0 {
+		return
+	}
+	oldest := c.order[0]
+	c.order = c.order[1:]
+	delete(c.nodes, oldest)
+}
+
+// Close syncs and releases the tree's PageStore, if it has one (set by
+// OpenBPlusTree). It's a no-op for a purely in-memory tree. It does not
+// write the in-memory nodes to the store; FlushBPlusTree does that.
+func (t *BPlusTree[K, V]) Close() error {
+	if t.store == nil {
+		return nil
+	}
+	if err := t.store.Sync(); err != nil {
+		return err
+	}
+	if closer, ok := t.store.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// decodedPage is the page-id-keyed form decodeNodePage produces: child
+// and next-sibling pointers aren't resolved into *BPlusNode yet, since
+// that requires reading (and possibly caching) further pages.
+type decodedPage struct {
+	isLeaf       bool
+	entries      []Entry[int, string]
+	keys         []int
+	childPageIDs []uint64
+	nextPageID   uint64
+}
+
+// encodeNodePage serializes n into the fixed page layout: a magic byte,
+// an isLeaf flag, a key count, then either (leaf) each key/value slot
+// followed by the right-sibling page id, or (internal) each key followed
+// by each child's page id. ids must already hold a page id for n and
+// (transitively) everything it points to.
+func encodeNodePage(n *BPlusNode[int, string], ids map[*BPlusNode[int, string]]uint64) []byte {
+	buf := make([]byte, 4, PageSize)
+	buf[0] = pageMagic
+	if n.IsLeaf {
+		buf[1] = 1
+		binary.LittleEndian.PutUint16(buf[2:4], uint16(len(n.Entries)))
+		for _, e := range n.Entries {
+			var slot [10]byte
+			binary.LittleEndian.PutUint64(slot[0:8], uint64(e.Key))
+			binary.LittleEndian.PutUint16(slot[8:10], uint16(len(e.Value)))
+			buf = append(buf, slot[:]...)
+			buf = append(buf, e.Value...)
+		}
+		var nextID uint64
+		if n.Next != nil {
+			nextID = ids[n.Next]
+		}
+		var nextBuf [8]byte
+		binary.LittleEndian.PutUint64(nextBuf[:], nextID)
+		return append(buf, nextBuf[:]...)
+	}
+
+	binary.LittleEndian.PutUint16(buf[2:4], uint16(len(n.Keys)))
+	for _, k := range n.Keys {
+		var keyBuf [8]byte
+		binary.LittleEndian.PutUint64(keyBuf[:], uint64(k))
+		buf = append(buf, keyBuf[:]...)
+	}
+	for _, child := range n.Children {
+		var idBuf [8]byte
+		binary.LittleEndian.PutUint64(idBuf[:], ids[child])
+		buf = append(buf, idBuf[:]...)
+	}
+	return buf
+}
+
+// decodeNodePage parses a page written by encodeNodePage back into its
+// keys/entries, leaving child and next-sibling references as page ids
+// for the caller to resolve.
+func decodeNodePage(data []byte) (*decodedPage, error) {
+	if len(data) < 4 || data[0] != pageMagic {
+		return nil, errors.New("bplus tree: page has a bad magic byte, not a node page")
+	}
+	d := &decodedPage{isLeaf: data[1] == 1}
+	count := int(binary.LittleEndian.Uint16(data[2:4]))
+	offset := 4
+
+	if d.isLeaf {
+		d.entries = make([]Entry[int, string], count)
+		for i := 0; i < count; i++ {
+			key := int(binary.LittleEndian.Uint64(data[offset : offset+8]))
+			vlen := int(binary.LittleEndian.Uint16(data[offset+8 : offset+10]))
+			offset += 10
+			d.entries[i] = Entry[int, string]{Key: key, Value: string(data[offset : offset+vlen])}
+			offset += vlen
+		}
+		d.nextPageID = binary.LittleEndian.Uint64(data[offset : offset+8])
+		return d, nil
+	}
+
+	d.keys = make([]int, count)
+	for i := 0; i < count; i++ {
+		d.keys[i] = int(binary.LittleEndian.Uint64(data[offset : offset+8]))
+		offset += 8
+	}
+	d.childPageIDs = make([]uint64, count+1)
+	for i := range d.childPageIDs {
+		d.childPageIDs[i] = binary.LittleEndian.Uint64(data[offset : offset+8])
+		offset += 8
+	}
+	return d, nil
+}
+
+// OpenBPlusTree opens the int/string B+ tree persisted at path (creating
+// an empty one if the file is new), materializing every page into an
+// ordinary in-memory tree so Insert/Search/Delete/etc. work exactly as
+// they do on a purely in-memory tree. Call FlushBPlusTree to write the current
+// in-memory state back out, and Close when done. The page codec above is
+// specific to int keys and string values - the same concrete pairing
+// NewBPlusTree and the rest of this package's DatabaseIndex/DatabaseTable
+// row-offset indexes already standardize on - since encoding an
+// arbitrary K/V generically would need reflection this package doesn't
+// otherwise use.
+func OpenBPlusTree(path string) (*BPlusTree[int, string], error) {
+	store, err := OpenFilePageStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	super, err := store.ReadPage(superblockPage)
+	if err != nil {
+		// Fresh file: start from an empty in-memory tree. Page id 0 is
+		// reserved for the superblock, so AllocatePage (which starts
+		// counting from 1) never hands it out to a node.
+		tree := NewBPlusTreeWithIntComparator()
+		tree.store = store
+		tree.cache = newNodeCache[int, string](nodeCacheSize)
+		return tree, nil
+	}
+
+	rootID := binary.LittleEndian.Uint64(super[0:8])
+	height := int(binary.LittleEndian.Uint64(super[8:16]))
+
+	loaded := make(map[uint64]*BPlusNode[int, string])
+	root, err := loadNode(store, rootID, loaded)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	tree := NewBPlusTreeWithIntComparator()
+	tree.Root = root
+	tree.Height = height
+	tree.store = store
+	tree.cache = newNodeCache[int, string](nodeCacheSize)
+	for id, node := range loaded {
+		node.cow = tree.cow
+		tree.cache.put(id, node)
+	}
+	return tree, nil
+}
+
+// loadNode decodes the page for id and every page it transitively
+// references, memoizing in loaded so a page reachable through more than
+// one path (every leaf is reachable both via its parent's Children and
+// via the previous leaf's Next) is decoded into exactly one object.
+func loadNode(store PageStore, id uint64, loaded map[uint64]*BPlusNode[int, string]) (*BPlusNode[int, string], error) {
+	if n, ok := loaded[id]; ok {
+		return n, nil
+	}
+	data, err := store.ReadPage(id)
+	if err != nil {
+		return nil, err
+	}
+	d, err := decodeNodePage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &BPlusNode[int, string]{IsLeaf: d.isLeaf}
+	loaded[id] = node
+
+	if d.isLeaf {
+		node.Entries = d.entries
+		if d.nextPageID != 0 {
+			next, err := loadNode(store, d.nextPageID, loaded)
+			if err != nil {
+				return nil, err
+			}
+			node.Next = next
+		}
+		return node, nil
+	}
+
+	node.Keys = d.keys
+	node.Children = make([]*BPlusNode[int, string], len(d.childPageIDs))
+	for i, childID := range d.childPageIDs {
+		child, err := loadNode(store, childID, loaded)
+		if err != nil {
+			return nil, err
+		}
+		child.Parent = node
+		node.Children[i] = child
+	}
+	return node, nil
+}
+
+// FlushBPlusTree writes every node of t into its PageStore (t must have
+// been returned by OpenBPlusTree) and updates the superblock to point at
+// the current root, so a subsequent OpenBPlusTree on the same path picks
+// up exactly this state.
+func FlushBPlusTree(t *BPlusTree[int, string]) error {
+	if t.store == nil {
+		return errors.New("bplus tree: Flush called on a tree with no PageStore (use OpenBPlusTree first)")
+	}
+
+	ids := make(map[*BPlusNode[int, string]]uint64)
+	assignPageIDs(t.store, t.Root, ids)
+	written := make(map[*BPlusNode[int, string]]bool)
+	if err := writePages(t.store, t.Root, ids, written); err != nil {
+		return err
+	}
+
+	super := make([]byte, 16)
+	binary.LittleEndian.PutUint64(super[0:8], ids[t.Root])
+	binary.LittleEndian.PutUint64(super[8:16], uint64(t.Height))
+	if err := t.store.WritePage(superblockPage, super); err != nil {
+		return err
+	}
+	return t.store.Sync()
+}
+
+// assignPageIDs walks every node reachable from n (through Children and
+// the leaf Next chain) and hands out a page id to each one that doesn't
+// already have one in ids.
+func assignPageIDs(store PageStore, n *BPlusNode[int, string], ids map[*BPlusNode[int, string]]uint64) {
+	if _, ok := ids[n]; ok {
+		return
+	}
+	ids[n] = store.AllocatePage()
+	if n.IsLeaf {
+		if n.Next != nil {
+			assignPageIDs(store, n.Next, ids)
+		}
+		return
+	}
+	for _, child := range n.Children {
+		assignPageIDs(store, child, ids)
+	}
+}
+
+// writePages encodes and writes every node reachable from n exactly
+// once, using written to skip a leaf already reached via another node's
+// Next pointer.
+func writePages(store PageStore, n *BPlusNode[int, string], ids map[*BPlusNode[int, string]]uint64, written map[*BPlusNode[int, string]]bool) error {
+	if written[n] {
+		return nil
+	}
+	written[n] = true
+
+	if err := store.WritePage(ids[n], encodeNodePage(n, ids)); err != nil {
+		return err
+	}
+	if n.IsLeaf {
+		if n.Next != nil {
+			return writePages(store, n.Next, ids, written)
+		}
+		return nil
+	}
+	for _, child := range n.Children {
+		if err := writePages(store, child, ids, written); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import "fmt"
+
+// Validate walks the tree and returns an error describing the first
+// broken invariant it finds, or nil if the tree is well-formed. It
+// checks that every leaf sits at the same depth, every non-root node
+// holds between MinDegree-1 and 2*MinDegree-1 keys, every internal
+// separator key equals the minimum key of its right subtree, and the
+// leaf linked list visits keys in strictly increasing order. It's meant
+// as a safety net for exercising new operations against, the same way
+// this bug surfaced the original splitChild only ever handling the leaf
+// case.
+//
+// Parent isn't checked here: a node that hasn't been written through
+// since a Copy() still carries the Parent it had at that snapshot, which
+// can be one generation behind the tree currently holding it (see
+// BPlusNode.Parent), so it isn't part of the structural invariant this
+// validates.
+func (t *BPlusTree[K, V]) Validate() error {
+	t.rlock()
+	defer t.runlock()
+
+	leafDepth := -1
+	if err := t.validateNode(t.Root, 0, true, &leafDepth); err != nil {
+		return err
+	}
+	return t.validateLeafOrder()
+}
+
+// validateNode recursively checks key-count bounds, child-count
+// consistency, and separator correctness for node and everything below
+// it, recording (and cross-checking) the depth every leaf is found at.
+func (t *BPlusTree[K, V]) validateNode(node *BPlusNode[K, V], depth int, isRoot bool, leafDepth *int) error {
+	if !isRoot && node.NumKeys() < t.MinDegree-1 {
+		return fmt.Errorf("bplus tree: node at depth %d has %d keys, fewer than MinDegree-1 (%d)", depth, node.NumKeys(), t.MinDegree-1)
+	}
+	if node.NumKeys() > 2*t.MinDegree-1 {
+		return fmt.Errorf("bplus tree: node at depth %d has %d keys, more than 2*MinDegree-1 (%d)", depth, node.NumKeys(), 2*t.MinDegree-1)
+	}
+
+	if node.IsLeaf {
+		if *leafDepth == -1 {
+			*leafDepth = depth
+		} else if depth != *leafDepth {
+			return fmt.Errorf("bplus tree: leaf at depth %d, expected every leaf at depth %d", depth, *leafDepth)
+		}
+		return nil
+	}
+
+	if len(node.Children) != len(node.Keys)+1 {
+		return fmt.Errorf("bplus tree: internal node at depth %d has %d keys but %d children", depth, len(node.Keys), len(node.Children))
+	}
+	for i, key := range node.Keys {
+		want := t.subtreeMinKey(node.Children[i+1])
+		if t.Compare(key, want) != 0 {
+			return fmt.Errorf("bplus tree: separator %v at depth %d index %d should equal right subtree's min key %v", key, depth, i, want)
+		}
+	}
+	for _, child := range node.Children {
+		if err := t.validateNode(child, depth+1, false, leafDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLeafOrder walks the leaf-level Next chain from the tree's
+// first leaf and confirms keys strictly increase across the whole chain.
+func (t *BPlusTree[K, V]) validateLeafOrder() error {
+	node := t.Root
+	for !node.IsLeaf && len(node.Children) > 0 {
+		node = node.Children[0]
+	}
+
+	var prev K
+	havePrev := false
+	for node != nil {
+		for _, entry := range node.Entries {
+			if havePrev && t.Compare(prev, entry.Key) >= 0 {
+				return fmt.Errorf("bplus tree: leaf chain out of order: %v should sort before %v", prev, entry.Key)
+			}
+			prev = entry.Key
+			havePrev = true
+		}
+		node = node.Next
+	}
+	return nil
+}
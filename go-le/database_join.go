@@ -0,0 +1,479 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+)
+
+// JoinTableRef names one table participating in a join, with an alias
+// generated the same way a generic join-plan structure auto-names
+// unaliased participants ("T1", "T2", ...) - see NewJoinSpec.
+type JoinTableRef struct {
+	Table string
+	Alias string
+}
+
+// JoinCondition is one equi-join edge between two aliases already
+// registered on the enclosing JoinSpec: LeftAlias.LeftColumn =
+// RightAlias.RightColumn. Outer marks the edge as a left-outer join
+// that preserves rows already joined in even when this edge finds no
+// match, rather than an inner join that drops them.
+type JoinCondition struct {
+	LeftAlias   string
+	LeftColumn  string
+	RightAlias  string
+	RightColumn string
+	Outer       bool
+}
+
+// JoinSpec describes a multi-table join: the tables involved (with
+// aliases), the equi-join edges connecting them, and a set of per-alias
+// predicates - the same condition-map shape QueryData takes - narrowing
+// each table before it's joined.
+type JoinSpec struct {
+	Tables     []JoinTableRef
+	Conditions []JoinCondition
+	Predicates map[string]map[string]string
+}
+
+// NewJoinSpec builds a JoinSpec over tableNames, assigning each table
+// the default alias "T%d" (T1, T2, ...) the way a generic join-plan
+// structure auto-names unaliased participants.
+func NewJoinSpec(tableNames ...string) *JoinSpec {
+	tables := make([]JoinTableRef, len(tableNames))
+	for i, name := range tableNames {
+		tables[i] = JoinTableRef{Table: name, Alias: fmt.Sprintf("T%d", i+1)}
+	}
+	return &JoinSpec{Tables: tables, Predicates: make(map[string]map[string]string)}
+}
+
+// Join adds an equi-join edge between two of the spec's aliases.
+func (js *JoinSpec) Join(leftAlias, leftColumn, rightAlias, rightColumn string, outer bool) {
+	js.Conditions = append(js.Conditions, JoinCondition{
+		LeftAlias: leftAlias, LeftColumn: leftColumn,
+		RightAlias: rightAlias, RightColumn: rightColumn,
+		Outer: outer,
+	})
+}
+
+// Where attaches a QueryData-style condition map to one alias, applied
+// to that table before it participates in any join.
+func (js *JoinSpec) Where(alias string, conditions map[string]string) {
+	js.Predicates[alias] = conditions
+}
+
+// tableFor resolves an alias back to the table name JoinSpec.Tables
+// registered it under.
+func (js *JoinSpec) tableFor(alias string) string {
+	for _, t := range js.Tables {
+		if t.Alias == alias {
+			return t.Table
+		}
+	}
+	return ""
+}
+
+// edgeKey is the joined-name path a join edge is keyed by for dedup,
+// e.g. "orders.user_id=users.user_id" - built from table names rather
+// than aliases, so the same join expressed under different aliases is
+// still recognized as the same edge, with the two sides ordered so the
+// key doesn't depend on which side was written as "left".
+func (js *JoinSpec) edgeKey(jc JoinCondition) string {
+	left := js.tableFor(jc.LeftAlias) + "." + jc.LeftColumn
+	right := js.tableFor(jc.RightAlias) + "." + jc.RightColumn
+	if left > right {
+		left, right = right, left
+	}
+	return left + "=" + right
+}
+
+// dedupeEdges drops repeated join edges from spec.Conditions (by
+// edgeKey) - the "joinTables" bookkeeping a planner needs before it can
+// safely cost and execute each distinct edge exactly once.
+func dedupeEdges(spec *JoinSpec) []JoinCondition {
+	seen := make(map[string]bool, len(spec.Conditions))
+	edges := make([]JoinCondition, 0, len(spec.Conditions))
+	for _, jc := range spec.Conditions {
+		key := spec.edgeKey(jc)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		edges = append(edges, jc)
+	}
+	return edges
+}
+
+// estimateFilteredRows estimates how many rows tableName will have
+// after conditions are applied, using the same ColumnStats/selectivity
+// machinery OptimizeQuery costs single-table index choices with - the
+// signal QueryJoin orders its joins by.
+func estimateFilteredRows(ds *DatabaseSystem, tableName string, conditions map[string]string) int {
+	table, ok := ds.Tables[tableName]
+	if !ok {
+		return 1
+	}
+	realtimeRowCount := len(table.BTreeIndex.Root.Entries)
+
+	best := realtimeRowCount
+	if best < 1 {
+		best = 1
+	}
+	for _, p := range parsePredicates(conditions) {
+		stats := ds.Optimizer.Stats[statsKey(tableName, p.Column)]
+		if stats == nil {
+			continue
+		}
+		if est := estimateRows(stats, p, realtimeRowCount); est < best {
+			best = est
+		}
+	}
+	return best
+}
+
+// QueryJoin executes spec: every table is first narrowed by its own
+// per-alias predicates (QueryData), then the distinct join edges (see
+// dedupeEdges) are executed one at a time in an order chosen by
+// estimateFilteredRows - the table expected to produce the fewest rows
+// after its own predicates drives the join, and each later edge extends
+// the accumulated result with whichever unjoined table is cheapest to
+// add next. An edge is executed as an index-nested-loop when the
+// joined-in table has a btree index on the join column and no
+// predicates of its own (so the index's stored row offsets still line
+// up with its row order), or as a hash join over the smaller side's
+// Arrow column otherwise.
+func (ds *DatabaseSystem) QueryJoin(spec *JoinSpec) (arrow.Table, error) {
+	if len(spec.Tables) == 0 {
+		return nil, fmt.Errorf("join spec has no tables")
+	}
+
+	edges := dedupeEdges(spec)
+
+	loaded := make(map[string]arrow.Table, len(spec.Tables))
+	defer func() {
+		for _, t := range loaded {
+			if t != nil {
+				t.Release()
+			}
+		}
+	}()
+
+	estRows := make(map[string]int, len(spec.Tables))
+	for _, ref := range spec.Tables {
+		table, exists := ds.Tables[ref.Table]
+		if !exists {
+			return nil, fmt.Errorf("table %s does not exist", ref.Table)
+		}
+
+		conditions := spec.Predicates[ref.Alias]
+		data, err := table.QueryData(conditions, ds.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s (%s): %w", ref.Alias, ref.Table, err)
+		}
+		if data == nil {
+			data = emptyTableFor(table)
+		}
+
+		prefixed := prefixColumns(data, ref.Alias)
+		data.Release()
+		loaded[ref.Alias] = prefixed
+		estRows[ref.Alias] = estimateFilteredRows(ds, ref.Table, conditions)
+	}
+
+	order := make([]string, len(spec.Tables))
+	for i, ref := range spec.Tables {
+		order[i] = ref.Alias
+	}
+	sort.Slice(order, func(i, j int) bool { return estRows[order[i]] < estRows[order[j]] })
+
+	joinedSet := map[string]bool{order[0]: true}
+	result := loaded[order[0]]
+	result.Retain()
+
+	for len(joinedSet) < len(spec.Tables) {
+		edge, nextAlias, ok := pickNextEdge(edges, joinedSet, estRows)
+		if !ok {
+			result.Release()
+			return nil, fmt.Errorf("join graph is disconnected: no edge reaches the remaining tables")
+		}
+
+		combined, err := ds.executeJoinEdge(spec, edge, joinedSet, result, loaded[nextAlias], nextAlias)
+		result.Release()
+		if err != nil {
+			return nil, err
+		}
+		result = combined
+		joinedSet[nextAlias] = true
+	}
+
+	return result, nil
+}
+
+// pickNextEdge finds the cheapest edge connecting joinedSet to exactly
+// one alias outside it, preferring the candidate with the lowest
+// estRows - greedily growing the join by whichever unjoined table looks
+// cheapest to add next.
+func pickNextEdge(edges []JoinCondition, joinedSet map[string]bool, estRows map[string]int) (JoinCondition, string, bool) {
+	bestIdx := -1
+	var bestAlias string
+	bestEst := -1
+	for i, e := range edges {
+		var newAlias string
+		switch {
+		case joinedSet[e.LeftAlias] && !joinedSet[e.RightAlias]:
+			newAlias = e.RightAlias
+		case joinedSet[e.RightAlias] && !joinedSet[e.LeftAlias]:
+			newAlias = e.LeftAlias
+		default:
+			continue
+		}
+		if bestIdx == -1 || estRows[newAlias] < bestEst {
+			bestIdx, bestAlias, bestEst = i, newAlias, estRows[newAlias]
+		}
+	}
+	if bestIdx == -1 {
+		return JoinCondition{}, "", false
+	}
+	return edges[bestIdx], bestAlias, true
+}
+
+// executeJoinEdge joins accumulated (already holding every alias in
+// joinedSet, columns named "alias.column") with next (the single new
+// alias nextAlias being added), matching on edge's join columns.
+func (ds *DatabaseSystem) executeJoinEdge(spec *JoinSpec, edge JoinCondition, joinedSet map[string]bool, accumulated, next arrow.Table, nextAlias string) (arrow.Table, error) {
+	var accumCol, nextCol, nextColumnName string
+	if joinedSet[edge.LeftAlias] {
+		accumCol = edge.LeftAlias + "." + edge.LeftColumn
+		nextCol = edge.RightAlias + "." + edge.RightColumn
+		nextColumnName = edge.RightColumn
+	} else {
+		accumCol = edge.RightAlias + "." + edge.RightColumn
+		nextCol = edge.LeftAlias + "." + edge.LeftColumn
+		nextColumnName = edge.LeftColumn
+	}
+
+	accumIdx := fieldIndex(accumulated.Schema(), accumCol)
+	nextIdx := fieldIndex(next.Schema(), nextCol)
+	if accumIdx < 0 || nextIdx < 0 {
+		return nil, fmt.Errorf("join column %s or %s not found", accumCol, nextCol)
+	}
+
+	nextTableName := spec.tableFor(nextAlias)
+	nextUnfiltered := len(spec.Predicates[nextAlias]) == 0
+
+	var pairs [][2]int
+	if index, ok := ds.Tables[nextTableName].ColumnIndexes[nextColumnName]; ok && index.IndexType == "btree" && nextUnfiltered {
+		pairs = indexNestedLoopPairs(index.BTreeIndex, accumulated.Column(accumIdx), int(next.NumRows()), edge.Outer)
+	} else {
+		pairs = hashJoinPairs(accumulated.Column(accumIdx), next.Column(nextIdx), edge.Outer)
+	}
+
+	return materializeJoin(accumulated, next, pairs), nil
+}
+
+// indexNestedLoopPairs probes tree with each row of accumCol (the
+// driving, already-joined side), looking up the matching row in the
+// joined-in table by the row offset parquet-style ":N" suffix stored in
+// the index's value - see updateIndexes, which records that offset at
+// insert time. A non-numeric or unmatched probe value drops the row
+// unless outer keeps it with a -1 (unmatched) partner.
+func indexNestedLoopPairs(tree *BPlusTree[int, string], accumCol *arrow.Column, nextRowCount int, outer bool) [][2]int {
+	pairs := make([][2]int, 0, accumCol.Len())
+	for i := 0; i < accumCol.Len(); i++ {
+		key, ok := parseInt64(columnValue(accumCol, i))
+		if ok {
+			if value, found := tree.Search(int(key)); found {
+				if offset, ok := parseIndexRowOffset(value); ok && offset >= 0 && offset < nextRowCount {
+					pairs = append(pairs, [2]int{i, offset})
+					continue
+				}
+			}
+		}
+		if outer {
+			pairs = append(pairs, [2]int{i, -1})
+		}
+	}
+	return pairs
+}
+
+// parseIndexRowOffset extracts the trailing ":N" row offset from a
+// BPlusTree index value (e.g. "orders_data.parquet:4" -> 4), the
+// position updateIndexes recorded the row at within its table's
+// insertion order.
+func parseIndexRowOffset(value string) (int, bool) {
+	idx := strings.LastIndex(value, ":")
+	if idx == -1 {
+		return 0, false
+	}
+	v, err := strconv.Atoi(value[idx+1:])
+	return v, err == nil
+}
+
+// hashJoinPairs builds a hash index on whichever of leftCol/rightCol
+// has fewer rows and probes it with the other side, returning matched
+// (left row, right row) index pairs. outer keeps an unmatched left row
+// with a -1 (unmatched) right partner instead of dropping it; unmatched
+// right rows are always dropped, the usual left-outer-join convention.
+func hashJoinPairs(leftCol, rightCol *arrow.Column, outer bool) [][2]int {
+	pairs := make([][2]int, 0)
+
+	if leftCol.Len() <= rightCol.Len() {
+		index := buildColumnIndex(leftCol)
+		matchedLeft := make(map[int]bool, leftCol.Len())
+		for r := 0; r < rightCol.Len(); r++ {
+			for _, l := range index[columnValue(rightCol, r)] {
+				pairs = append(pairs, [2]int{l, r})
+				matchedLeft[l] = true
+			}
+		}
+		if outer {
+			for l := 0; l < leftCol.Len(); l++ {
+				if !matchedLeft[l] {
+					pairs = append(pairs, [2]int{l, -1})
+				}
+			}
+		}
+		return pairs
+	}
+
+	index := buildColumnIndex(rightCol)
+	for l := 0; l < leftCol.Len(); l++ {
+		matches, ok := index[columnValue(leftCol, l)]
+		if !ok {
+			if outer {
+				pairs = append(pairs, [2]int{l, -1})
+			}
+			continue
+		}
+		for _, r := range matches {
+			pairs = append(pairs, [2]int{l, r})
+		}
+	}
+	return pairs
+}
+
+// buildColumnIndex maps every value in col to the row indices holding
+// it, the build side of a hash join.
+func buildColumnIndex(col *arrow.Column) map[string][]int {
+	index := make(map[string][]int, col.Len())
+	for i := 0; i < col.Len(); i++ {
+		v := columnValue(col, i)
+		index[v] = append(index[v], i)
+	}
+	return index
+}
+
+// columnValue renders row of col as its cellString, assuming col holds
+// a single chunk - true for every table QueryJoin builds internally
+// (prefixColumns, materializeJoin and QueryData's own builders all
+// produce single-chunk columns).
+func columnValue(col *arrow.Column, row int) string {
+	return cellString(col.Data().Chunk(0), row)
+}
+
+// fieldIndex returns schema's field index for name, or -1 if absent.
+func fieldIndex(schema *arrow.Schema, name string) int {
+	idx := schema.FieldIndices(name)
+	if len(idx) == 0 {
+		return -1
+	}
+	return idx[0]
+}
+
+// prefixColumns returns a new table with every field renamed
+// "alias.column", so columns from different joined tables never
+// collide once their rows sit side-by-side in one result.
+func prefixColumns(tbl arrow.Table, alias string) arrow.Table {
+	schema := tbl.Schema()
+	fields := make([]arrow.Field, schema.NumFields())
+	cols := make([]arrow.Column, schema.NumFields())
+	for i, f := range schema.Fields() {
+		fields[i] = arrow.Field{Name: alias + "." + f.Name, Type: f.Type, Nullable: f.Nullable}
+		chunked := tbl.Column(i).Data()
+		chunked.Retain()
+		col := arrow.NewColumn(fields[i], chunked)
+		chunked.Release()
+		cols[i] = *col
+	}
+	return array.NewTable(arrow.NewSchema(fields, nil), cols, tbl.NumRows())
+}
+
+// emptyTableFor builds a zero-row arrow.Table matching table's declared
+// schema - the fallback QueryJoin uses when a participating table has
+// no data yet (QueryData returns a nil table in that case).
+func emptyTableFor(table *DatabaseTable) arrow.Table {
+	alloc := memory.NewGoAllocator()
+	fields := make([]arrow.Field, 0, len(table.Schema))
+	builders := make([]array.Builder, 0, len(table.Schema))
+	for name, colType := range table.Schema {
+		if colType == "int64" {
+			fields = append(fields, arrow.Field{Name: name, Type: arrow.PrimitiveTypes.Int64})
+			builders = append(builders, array.NewInt64Builder(alloc))
+		} else {
+			fields = append(fields, arrow.Field{Name: name, Type: arrow.BinaryTypes.String})
+			builders = append(builders, array.NewStringBuilder(alloc))
+		}
+	}
+	return buildFilteredTable(arrow.NewSchema(fields, nil), builders, 0)
+}
+
+// materializeJoin builds the joined table for pairs of (accumulated
+// row, next row) indices, copying accumulated's columns followed by
+// next's; a next index of -1 (an outer join's unmatched row) appends
+// nulls for every one of next's columns instead.
+func materializeJoin(accumulated, next arrow.Table, pairs [][2]int) arrow.Table {
+	alloc := memory.NewGoAllocator()
+	accumSchema, nextSchema := accumulated.Schema(), next.Schema()
+
+	fields := make([]arrow.Field, 0, accumSchema.NumFields()+nextSchema.NumFields())
+	fields = append(fields, accumSchema.Fields()...)
+	fields = append(fields, nextSchema.Fields()...)
+	schema := arrow.NewSchema(fields, nil)
+
+	builders := make([]array.Builder, len(fields))
+	for i, f := range fields {
+		if f.Type.ID() == arrow.INT64 {
+			builders[i] = array.NewInt64Builder(alloc)
+		} else {
+			builders[i] = array.NewStringBuilder(alloc)
+		}
+	}
+	defer func() {
+		for _, b := range builders {
+			b.Release()
+		}
+	}()
+
+	accumCols := make([]arrow.Array, accumSchema.NumFields())
+	for i := range accumCols {
+		accumCols[i] = accumulated.Column(i).Data().Chunk(0)
+	}
+	nextCols := make([]arrow.Array, nextSchema.NumFields())
+	for i := range nextCols {
+		nextCols[i] = next.Column(i).Data().Chunk(0)
+	}
+	numAccum := len(accumCols)
+
+	for _, pair := range pairs {
+		l, r := pair[0], pair[1]
+		for i, col := range accumCols {
+			appendCell(builders[i], fields[i].Type.ID(), col, l)
+		}
+		for i, col := range nextCols {
+			bi := numAccum + i
+			if r == -1 {
+				builders[bi].AppendNull()
+				continue
+			}
+			appendCell(builders[bi], fields[bi].Type.ID(), col, r)
+		}
+	}
+
+	return buildFilteredTable(schema, builders, int64(len(pairs)))
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// Migration is one versioned, registered schema change for a table. ID
+// must be monotonic and unique within the table's migration set - Migrate
+// sorts by ID rather than relying on registration order, since Go's
+// package-level init order across files isn't deterministic. Up applies
+// the change; Down is its inverse, used for rollback.
+type Migration struct {
+	ID   int
+	Name string
+	Up   func(table *DatabaseTable) error
+	Down func(table *DatabaseTable) error
+}
+
+// RegisterMigration adds m to tableName's migration set. It does not
+// apply m - call Migrate to apply every registered-but-unapplied
+// migration across all tables.
+func (ds *DatabaseSystem) RegisterMigration(tableName string, m *Migration) {
+	ds.Migrations[tableName] = append(ds.Migrations[tableName], m)
+}
+
+// appliedMigrationIDs parses the comma-separated list of migration IDs
+// already recorded under the "migrations" FractalMetadata key.
+func appliedMigrationIDs(table *DatabaseTable) map[int]bool {
+	applied := make(map[int]bool)
+	raw := table.FractalMetadata.GetMetadata("migrations")
+	if raw == "" {
+		return applied
+	}
+	for _, s := range strings.Split(raw, ",") {
+		if id, err := strconv.Atoi(s); err == nil {
+			applied[id] = true
+		}
+	}
+	return applied
+}
+
+// recordMigrationApplied adds id to table's recorded "migrations" list,
+// overwriting the entry in place (see FractalTree.SetMetadata) so a
+// later GetMetadata call sees every ID recorded so far rather than only
+// the first one ever stored.
+func recordMigrationApplied(table *DatabaseTable, applied map[int]bool, id int) {
+	applied[id] = true
+	ids := make([]int, 0, len(applied))
+	for existing := range applied {
+		ids = append(ids, existing)
+	}
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, existing := range ids {
+		parts[i] = strconv.Itoa(existing)
+	}
+	table.FractalMetadata.SetMetadata("migrations", strings.Join(parts, ","))
+}
+
+// Migrate applies every migration registered across every table that
+// hasn't already been recorded as applied, table by table (in
+// deterministic, sorted table-name order) and, within a table, in
+// ascending Migration.ID order. It stops and returns an error as soon as
+// one migration's Up fails, leaving everything up to that point applied -
+// already-applied migrations are untouched on a later retry, so Migrate
+// is safe to call again after fixing whatever caused the failure.
+func (ds *DatabaseSystem) Migrate() (map[string][]int, error) {
+	applied := make(map[string][]int)
+
+	tableNames := make([]string, 0, len(ds.Migrations))
+	for tableName := range ds.Migrations {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		table, exists := ds.Tables[tableName]
+		if !exists {
+			return applied, fmt.Errorf("migration registered for unknown table %s", tableName)
+		}
+
+		migrations := append([]*Migration(nil), ds.Migrations[tableName]...)
+		sort.Slice(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+
+		alreadyApplied := appliedMigrationIDs(table)
+		for _, m := range migrations {
+			if alreadyApplied[m.ID] {
+				continue
+			}
+			if err := m.Up(table); err != nil {
+				return applied, fmt.Errorf("migration %d (%s) on %s: %w", m.ID, m.Name, tableName, err)
+			}
+			recordMigrationApplied(table, alreadyApplied, m.ID)
+			applied[tableName] = append(applied[tableName], m.ID)
+		}
+	}
+
+	return applied, nil
+}
+
+// MigrationPlan is the result of a column-rewrite primitive: the files
+// it touched (or, in dry-run mode, would touch) and their total on-disk
+// size, since a column-level rewrite always reads and re-writes every
+// row of every backing file regardless of how small the actual change.
+type MigrationPlan struct {
+	Files          []string
+	EstimatedBytes int64
+}
+
+// AddColumn adds name (of type colType, one of DatabaseTable.Schema's
+// "int64"/string conventions) to dt's schema, backfilling defaultValue
+// into every existing row. dryRun reports which files would be rewritten
+// without touching any of them.
+func (dt *DatabaseTable) AddColumn(name, colType, defaultValue string, dryRun bool) (*MigrationPlan, error) {
+	if _, exists := dt.Schema[name]; exists {
+		return nil, fmt.Errorf("column %s already exists on %s", name, dt.Name)
+	}
+
+	newSchema := cloneSchema(dt.Schema)
+	newSchema[name] = colType
+
+	transform := func(row map[string]string) map[string]string {
+		row[name] = defaultValue
+		return row
+	}
+
+	return rewriteTableFiles(dt, newSchema, transform, dryRun)
+}
+
+// DropColumn removes name from dt's schema, discarding its values from
+// every backing file, and drops any index built on it (an index keyed on
+// a column that no longer exists can't be queried meaningfully). dryRun
+// reports which files would be rewritten without touching any of them.
+func (dt *DatabaseTable) DropColumn(name string, dryRun bool) (*MigrationPlan, error) {
+	if _, exists := dt.Schema[name]; !exists {
+		return nil, fmt.Errorf("column %s does not exist on %s", name, dt.Name)
+	}
+
+	newSchema := cloneSchema(dt.Schema)
+	delete(newSchema, name)
+
+	transform := func(row map[string]string) map[string]string {
+		delete(row, name)
+		return row
+	}
+
+	plan, err := rewriteTableFiles(dt, newSchema, transform, dryRun)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	delete(dt.ColumnIndexes, name)
+	return plan, nil
+}
+
+// RenameColumn renames oldName to newName in dt's schema and every
+// backing file. dryRun reports which files would be rewritten without
+// touching any of them.
+func (dt *DatabaseTable) RenameColumn(oldName, newName string, dryRun bool) (*MigrationPlan, error) {
+	colType, exists := dt.Schema[oldName]
+	if !exists {
+		return nil, fmt.Errorf("column %s does not exist on %s", oldName, dt.Name)
+	}
+	if _, exists := dt.Schema[newName]; exists {
+		return nil, fmt.Errorf("column %s already exists on %s", newName, dt.Name)
+	}
+
+	newSchema := cloneSchema(dt.Schema)
+	delete(newSchema, oldName)
+	newSchema[newName] = colType
+
+	transform := func(row map[string]string) map[string]string {
+		row[newName] = row[oldName]
+		delete(row, oldName)
+		return row
+	}
+
+	plan, err := rewriteTableFiles(dt, newSchema, transform, dryRun)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	if index, ok := dt.ColumnIndexes[oldName]; ok {
+		delete(dt.ColumnIndexes, oldName)
+		index.ColumnName = newName
+		dt.ColumnIndexes[newName] = index
+	}
+	return plan, nil
+}
+
+// ChangeColumnType changes name's declared type to newType, re-encoding
+// every existing value through castFn. dryRun reports which files would
+// be rewritten without touching any of them.
+func (dt *DatabaseTable) ChangeColumnType(name, newType string, castFn func(string) (string, error), dryRun bool) (*MigrationPlan, error) {
+	if _, exists := dt.Schema[name]; !exists {
+		return nil, fmt.Errorf("column %s does not exist on %s", name, dt.Name)
+	}
+
+	newSchema := cloneSchema(dt.Schema)
+	newSchema[name] = newType
+
+	var castErr error
+	transform := func(row map[string]string) map[string]string {
+		if castErr != nil {
+			return row
+		}
+		cast, err := castFn(row[name])
+		if err != nil {
+			castErr = fmt.Errorf("casting %s.%s value %q: %w", dt.Name, name, row[name], err)
+			return row
+		}
+		row[name] = cast
+		return row
+	}
+
+	plan, err := rewriteTableFiles(dt, newSchema, transform, dryRun)
+	if err != nil {
+		return plan, err
+	}
+	if castErr != nil {
+		return plan, castErr
+	}
+	return plan, nil
+}
+
+// cloneSchema returns a shallow copy of schema, so a primitive's new
+// schema can be built without mutating dt.Schema until the rewrite (or
+// dry-run estimate) has actually succeeded.
+func cloneSchema(schema map[string]string) map[string]string {
+	clone := make(map[string]string, len(schema))
+	for k, v := range schema {
+		clone[k] = v
+	}
+	return clone
+}
+
+// rewriteTableFiles is the shared column-rewrite primitive: every file
+// backing dt is read in full, each row is passed through transform, and
+// the result is re-encoded under newSchema. A non-dry run atomically
+// replaces each file in place (write to a temp file, then os.Rename over
+// the original) and, once every file is rewritten, swaps dt.Schema, the
+// FractalMetadata "schema" entry, and rebuilds the row index so its
+// offsets still line up with the rewritten files. If dt has no backing
+// files yet (an empty table), only the schema is updated - there's
+// nothing to rewrite.
+func rewriteTableFiles(dt *DatabaseTable, newSchema map[string]string, transform func(map[string]string) map[string]string, dryRun bool) (*MigrationPlan, error) {
+	plan := &MigrationPlan{}
+
+	fileListStr := dt.FractalMetadata.GetMetadata("files")
+	var files []string
+	for _, f := range strings.Split(fileListStr, ",") {
+		if f == "" {
+			continue
+		}
+		files = append(files, f)
+		if info, err := os.Stat(f); err == nil {
+			plan.EstimatedBytes += info.Size()
+		}
+	}
+	plan.Files = files
+
+	if dryRun {
+		return plan, nil
+	}
+
+	if len(files) == 0 {
+		dt.Schema = newSchema
+		dt.FractalMetadata.SetMetadata("schema", schemaString(newSchema))
+		return plan, nil
+	}
+
+	rowsPerFile := make([]int, len(files))
+	for i, filename := range files {
+		rows, err := readParquetFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filename, err)
+		}
+
+		migrated := make([]map[string]string, len(rows))
+		for r, row := range rows {
+			migrated[r] = transform(row)
+		}
+
+		tmpName := filename + ".migrating"
+		if err := writeParquetFile(tmpName, newSchema, migrated); err != nil {
+			os.Remove(tmpName)
+			return nil, fmt.Errorf("rewriting %s: %w", filename, err)
+		}
+		if err := os.Rename(tmpName, filename); err != nil {
+			os.Remove(tmpName)
+			return nil, fmt.Errorf("swapping in rewritten %s: %w", filename, err)
+		}
+		rowsPerFile[i] = len(rows)
+	}
+
+	dt.Schema = newSchema
+	dt.FractalMetadata.SetMetadata("schema", schemaString(newSchema))
+	dt.FractalMetadata.SetMetadata("files", strings.Join(files, ","))
+	rebuildRowIndex(dt, files, rowsPerFile)
+
+	return plan, nil
+}
+
+// schemaString renders schema the same "col:type,col:type" way
+// DatabaseTable.CreateTable does, so the "schema" FractalMetadata entry
+// stays in the format GetTableInfo and callers already expect.
+func schemaString(schema map[string]string) string {
+	var b strings.Builder
+	for colName, colType := range schema {
+		if b.Len() > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(colName)
+		b.WriteString(":")
+		b.WriteString(colType)
+	}
+	return b.String()
+}
+
+// rebuildRowIndex replaces dt.BTreeIndex with a fresh row-id -> "file:N"
+// mapping built from files and their row counts: row ids are 1-based and
+// run on across files in order, and N is the row's 0-based offset within
+// its own file. The mapping is rebuilt from scratch rather than carried
+// over from the old index.
+func rebuildRowIndex(dt *DatabaseTable, files []string, rowsPerFile []int) {
+	dt.BTreeIndex = NewBPlusTree()
+	rowID := 1
+	for i, filename := range files {
+		for offset := 0; offset < rowsPerFile[i]; offset++ {
+			dt.BTreeIndex.Insert(rowID, fmt.Sprintf("%s:%d", filename, offset))
+			rowID++
+		}
+	}
+}
+
+// readParquetFile reads every row group of filename in full (no
+// predicate filtering) into row-major string values keyed by column
+// name - the representation the column-rewrite primitives transform
+// before re-encoding into a new schema.
+func readParquetFile(filename string) ([]map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	alloc := memory.NewGoAllocator()
+	arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := arrowReader.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	recordReader, err := arrowReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recordReader.Release()
+
+	var rows []map[string]string
+	for recordReader.Next() {
+		rec := recordReader.Record()
+		for r := 0; r < int(rec.NumRows()); r++ {
+			row := make(map[string]string, schema.NumFields())
+			for i, field := range schema.Fields() {
+				row[field.Name] = cellString(rec.Column(i), r)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// writeParquetFile encodes rows under schema and writes them to
+// filename as a single Parquet row group, the same
+// pqarrow.NewFileWriter path InsertData uses.
+func writeParquetFile(filename string, schema map[string]string, rows []map[string]string) error {
+	alloc := memory.NewGoAllocator()
+
+	fields := make([]arrow.Field, 0, len(schema))
+	builders := make([]array.Builder, 0, len(schema))
+	for colName, colType := range schema {
+		if colType == "int64" {
+			fields = append(fields, arrow.Field{Name: colName, Type: arrow.PrimitiveTypes.Int64})
+			builders = append(builders, array.NewInt64Builder(alloc))
+		} else {
+			fields = append(fields, arrow.Field{Name: colName, Type: arrow.BinaryTypes.String})
+			builders = append(builders, array.NewStringBuilder(alloc))
+		}
+	}
+	defer func() {
+		for _, b := range builders {
+			b.Release()
+		}
+	}()
+
+	for _, row := range rows {
+		for i, field := range fields {
+			val, ok := row[field.Name]
+			switch field.Type.ID() {
+			case arrow.INT64:
+				if !ok || val == "" {
+					builders[i].AppendNull()
+					break
+				}
+				if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
+					builders[i].(*array.Int64Builder).Append(intVal)
+				} else {
+					builders[i].AppendNull()
+				}
+			default:
+				if !ok {
+					builders[i].AppendNull()
+					break
+				}
+				builders[i].(*array.StringBuilder).Append(val)
+			}
+		}
+	}
+
+	arrowSchema := arrow.NewSchema(fields, nil)
+	arrs := make([]arrow.Array, len(builders))
+	for i, b := range builders {
+		arrs[i] = b.NewArray()
+	}
+	defer func() {
+		for _, a := range arrs {
+			a.Release()
+		}
+	}()
+
+	record := array.NewRecord(arrowSchema, arrs, int64(len(rows)))
+	defer record.Release()
+
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	writer, err := pqarrow.NewFileWriter(arrowSchema, out, parquet.NewWriterProperties(), pqarrow.NewArrowWriterProperties())
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return writer.Write(record)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,12 +20,216 @@ import (
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 )
 
+// predicateOp is an operator suffix on a query condition key, e.g. the
+// "gt" in "price__gt". A bare column name with no "__op" suffix means
+// opExact, matching the Django/ORM convention this is modeled on.
+type predicateOp string
+
+const (
+	opExact       predicateOp = "exact"
+	opIExact      predicateOp = "iexact"
+	opContains    predicateOp = "contains"
+	opIContains   predicateOp = "icontains"
+	opStartswith  predicateOp = "startswith"
+	opEndswith    predicateOp = "endswith"
+	opIStartswith predicateOp = "istartswith"
+	opIEndswith   predicateOp = "iendswith"
+	opGt          predicateOp = "gt"
+	opGte         predicateOp = "gte"
+	opLt          predicateOp = "lt"
+	opLte         predicateOp = "lte"
+	opIn          predicateOp = "in"
+	opIsnull      predicateOp = "isnull"
+	opBetween     predicateOp = "between"
+)
+
+// isKnownOp reports whether op is one parsePredicates recognizes as a
+// suffix; an unrecognized "word" after "__" is treated as part of the
+// column name instead (e.g. a column literally named "first__name").
+func isKnownOp(op predicateOp) bool {
+	switch op {
+	case opExact, opIExact, opContains, opIContains, opStartswith, opEndswith,
+		opIStartswith, opIEndswith, opGt, opGte, opLt, opLte, opIn, opIsnull, opBetween:
+		return true
+	}
+	return false
+}
+
+// Predicate is one parsed query condition: a column, the operator its
+// key was suffixed with, and the (possibly comma-separated, for in/
+// between) value it's compared against.
+type Predicate struct {
+	Column string
+	Op     predicateOp
+	Value  string
+}
+
+// parsePredicates splits each condition key into (column, op) on its
+// last "__", e.g. "signup_date__gte" -> {Column: "signup_date", Op:
+// opGte}. A key with no recognized "__op" suffix is an exact match on
+// the whole key, so existing equality-only callers keep working
+// unchanged.
+func parsePredicates(conditions map[string]string) []Predicate {
+	preds := make([]Predicate, 0, len(conditions))
+	for key, value := range conditions {
+		column, op := key, opExact
+		if idx := strings.LastIndex(key, "__"); idx != -1 && idx+2 < len(key) {
+			if suffix := predicateOp(key[idx+2:]); isKnownOp(suffix) {
+				column, op = key[:idx], suffix
+			}
+		}
+		preds = append(preds, Predicate{Column: column, Op: op, Value: value})
+	}
+	return preds
+}
+
+// isRangeShaped reports whether p's op describes a contiguous key range,
+// the shape a B+ tree index can answer directly rather than needing a
+// row-by-row filter.
+func (p Predicate) isRangeShaped() bool {
+	switch p.Op {
+	case opExact, opGt, opGte, opLt, opLte, opBetween:
+		return true
+	default:
+		return false
+	}
+}
+
+// selectivity scores how narrowing p's op is as an index lookup, highest
+// first: an exact match pins down a single key, a between scans a
+// bounded slice, and a one-sided gt/gte/lt/lte scans everything to one
+// side of it.
+func (p Predicate) selectivity() int {
+	switch p.Op {
+	case opExact:
+		return 100
+	case opBetween:
+		return 80
+	case opGte, opLte:
+		return 60
+	case opGt, opLt:
+		return 50
+	default:
+		return 0
+	}
+}
+
+// btreeRange converts p into an inclusive [lo, hi] int key range for a
+// BPlusTree.RangeQuery, the way a numeric column's B+ tree index is
+// keyed. It fails (ok=false) for a non-range-shaped op or a value that
+// doesn't parse as an int, in which case the caller falls back to the
+// row-by-row Arrow filter instead.
+func (p Predicate) btreeRange() (lo, hi int, ok bool) {
+	atoi := func(s string) (int, bool) {
+		v, err := strconv.Atoi(strings.TrimSpace(s))
+		return v, err == nil
+	}
+	switch p.Op {
+	case opExact:
+		v, ok := atoi(p.Value)
+		return v, v, ok
+	case opGte:
+		v, ok := atoi(p.Value)
+		return v, math.MaxInt, ok
+	case opGt:
+		v, ok := atoi(p.Value)
+		return v + 1, math.MaxInt, ok
+	case opLte:
+		v, ok := atoi(p.Value)
+		return math.MinInt, v, ok
+	case opLt:
+		v, ok := atoi(p.Value)
+		return math.MinInt, v - 1, ok
+	case opBetween:
+		parts := strings.SplitN(p.Value, ",", 2)
+		if len(parts) != 2 {
+			return 0, 0, false
+		}
+		lo, loOK := atoi(parts[0])
+		hi, hiOK := atoi(parts[1])
+		return lo, hi, loOK && hiOK
+	default:
+		return 0, 0, false
+	}
+}
+
+// matches evaluates p against one row's string-formatted value for
+// p.Column. Ordered ops try a numeric comparison first and fall back to
+// a lexicographic one if either side doesn't parse as a float, so the
+// same predicate works whether the underlying column is int64, a
+// float-like string, or plain text.
+func (p Predicate) matches(value string) bool {
+	switch p.Op {
+	case opExact:
+		return value == p.Value
+	case opIExact:
+		return strings.EqualFold(value, p.Value)
+	case opContains:
+		return strings.Contains(value, p.Value)
+	case opIContains:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(p.Value))
+	case opStartswith:
+		return strings.HasPrefix(value, p.Value)
+	case opEndswith:
+		return strings.HasSuffix(value, p.Value)
+	case opIStartswith:
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(p.Value))
+	case opIEndswith:
+		return strings.HasSuffix(strings.ToLower(value), strings.ToLower(p.Value))
+	case opIsnull:
+		want := p.Value == "true" || p.Value == "1"
+		return (value == "") == want
+	case opIn:
+		for _, v := range strings.Split(p.Value, ",") {
+			if value == strings.TrimSpace(v) {
+				return true
+			}
+		}
+		return false
+	case opGt:
+		return compareOrdered(value, p.Value) > 0
+	case opGte:
+		return compareOrdered(value, p.Value) >= 0
+	case opLt:
+		return compareOrdered(value, p.Value) < 0
+	case opLte:
+		return compareOrdered(value, p.Value) <= 0
+	case opBetween:
+		parts := strings.SplitN(p.Value, ",", 2)
+		if len(parts) != 2 {
+			return false
+		}
+		return compareOrdered(value, parts[0]) >= 0 && compareOrdered(value, parts[1]) <= 0
+	default:
+		return false
+	}
+}
+
+// compareOrdered returns <0, 0, or >0 as a sorts before, equals, or
+// sorts after b, comparing numerically if both parse as float64 and
+// lexicographically otherwise.
+func compareOrdered(a, b string) int {
+	af, aErr := strconv.ParseFloat(a, 64)
+	bf, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
 // DatabaseIndex represents an index on a database table
 type DatabaseIndex struct {
 	TableName    string
 	ColumnName   string
 	IndexType    string // "btree" or "fractal"
-	BTreeIndex   *BPlusTree
+	BTreeIndex   *BPlusTree[int, string]
 	FractalIndex *FractalTree
 }
 
@@ -41,33 +247,87 @@ func NewDatabaseIndex(tableName, columnName, indexType string) *DatabaseIndex {
 // QueryOptimizer optimizes queries using available indexes
 type QueryOptimizer struct {
 	Indexes []*DatabaseIndex
+	// Stats holds a ColumnStats per indexed "table.column", refreshed on
+	// every InsertData (see RecordInsert) and consulted by OptimizeQuery
+	// to cost candidate index plans instead of just listing every index
+	// whose predicate shape it could in principle answer.
+	Stats map[string]*ColumnStats
 }
 
 // NewQueryOptimizer creates a new query optimizer
 func NewQueryOptimizer() *QueryOptimizer {
 	return &QueryOptimizer{
 		Indexes: make([]*DatabaseIndex, 0),
+		Stats:   make(map[string]*ColumnStats),
 	}
 }
 
-// AddIndex adds an index to the optimizer
+// AddIndex adds an index to the optimizer, creating its ColumnStats
+// (empty until the first RecordInsert) if this is the first index on
+// this table+column.
 func (qo *QueryOptimizer) AddIndex(index *DatabaseIndex) {
 	qo.Indexes = append(qo.Indexes, index)
+	key := statsKey(index.TableName, index.ColumnName)
+	if _, ok := qo.Stats[key]; !ok {
+		qo.Stats[key] = NewColumnStats(index.TableName, index.ColumnName)
+	}
 }
 
-// OptimizeQuery returns applicable indexes for a query
-func (qo *QueryOptimizer) OptimizeQuery(tableName string, conditions map[string]string) []string {
-	applicableIndexes := make([]string, 0)
+// RecordInsert folds one InsertData batch's per-column values into every
+// indexed column's ColumnStats, so the next OptimizeQuery call costs
+// plans against up-to-date NDV/histogram data rather than whatever was
+// true when the index was created.
+func (qo *QueryOptimizer) RecordInsert(tableName string, data map[string][]string) {
+	for _, index := range qo.Indexes {
+		if index.TableName != tableName {
+			continue
+		}
+		values, exists := data[index.ColumnName]
+		if !exists {
+			continue
+		}
+		qo.Stats[statsKey(tableName, index.ColumnName)].Observe(values)
+	}
+}
+
+// OptimizeQuery costs every index applicable to conditions against
+// ColumnStats and returns them as IndexPlans sorted by estimated rows
+// scanned, ascending, so the cheapest-looking plan is tried first. An
+// index is only applicable at all when some predicate against its
+// column is range-shaped (see Predicate.isRangeShaped) - an index can't
+// help contains/icontains/in/isnull-style predicates, which need a
+// row-by-row filter regardless of any estimate.
+func (qo *QueryOptimizer) OptimizeQuery(tableName string, conditions map[string]string, realtimeRowCount int) []IndexPlan {
+	preds := parsePredicates(conditions)
+	plans := make([]IndexPlan, 0)
 
 	for _, index := range qo.Indexes {
-		if index.TableName == tableName {
-			if _, exists := conditions[index.ColumnName]; exists {
-				applicableIndexes = append(applicableIndexes, index.IndexType+"_"+index.ColumnName)
+		if index.TableName != tableName {
+			continue
+		}
+		var best *Predicate
+		for i, p := range preds {
+			if p.Column != index.ColumnName || !p.isRangeShaped() {
+				continue
+			}
+			if best == nil || p.selectivity() > best.selectivity() {
+				best = &preds[i]
 			}
 		}
+		if best == nil {
+			continue
+		}
+
+		stats := qo.Stats[statsKey(tableName, index.ColumnName)]
+		plans = append(plans, IndexPlan{
+			IndexType:  index.IndexType,
+			ColumnName: index.ColumnName,
+			EstRows:    estimateRows(stats, *best, realtimeRowCount),
+		})
 	}
 
-	return applicableIndexes
+	sort.Slice(plans, func(i, j int) bool { return plans[i].EstRows < plans[j].EstRows })
+	return plans
 }
 
 // PerformanceMetrics tracks database performance
@@ -75,6 +335,11 @@ type PerformanceMetrics struct {
 	QueryTimes        []int64
 	IndexHitRates     []float64
 	CompressionRatios []float64
+	// RowGroupsPruned counts Parquet row groups QueryData has skipped
+	// across every query so far because their column-chunk min/max
+	// statistics couldn't satisfy the query's predicates (see
+	// rowGroupsSatisfiable), so their rows never had to be read at all.
+	RowGroupsPruned int64
 }
 
 // NewPerformanceMetrics creates new performance metrics
@@ -91,6 +356,12 @@ func (pm *PerformanceMetrics) RecordQueryTime(timeUs int64) {
 	pm.QueryTimes = append(pm.QueryTimes, timeUs)
 }
 
+// RecordRowGroupsPruned adds n to the running count of row groups
+// skipped via Parquet column-chunk statistics pruning.
+func (pm *PerformanceMetrics) RecordRowGroupsPruned(n int) {
+	pm.RowGroupsPruned += int64(n)
+}
+
 // GetAverageQueryTime calculates average query time
 func (pm *PerformanceMetrics) GetAverageQueryTime() float64 {
 	if len(pm.QueryTimes) == 0 {
@@ -134,13 +405,33 @@ func (ft *FractalTree) GetMetadata(key string) string {
 	return ""
 }
 
+// SetMetadata replaces key's value in place if it's already present,
+// falling back to StoreMetadata otherwise. Callers that need an entry
+// (e.g. "files" or "migrations") to be overwritten atomically rather than
+// shadowed by a stale earlier GetMetadata hit use this instead of
+// StoreMetadata.
+func (ft *FractalTree) SetMetadata(key, value string) {
+	for i, k := range ft.MetadataKeys {
+		if k == key {
+			ft.MetadataValues[i] = value
+			return
+		}
+	}
+	ft.StoreMetadata(key, value)
+}
+
 // DatabaseTable represents a database table
 type DatabaseTable struct {
 	Name            string
-	BTreeIndex      *BPlusTree
+	BTreeIndex      *BPlusTree[int, string]
 	FractalMetadata *FractalTree
 	Schema          map[string]string
 	DataDir         string
+	// ColumnIndexes maps a column name to the DatabaseIndex built on it,
+	// so QueryData can tell a range-shaped predicate apart from one that
+	// needs a row-by-row Arrow filter. Populated by DatabaseSystem.
+	// CreateIndex.
+	ColumnIndexes map[string]*DatabaseIndex
 }
 
 // NewDatabaseTable creates a new database table
@@ -151,6 +442,7 @@ func NewDatabaseTable(name, dataDir string) *DatabaseTable {
 		FractalMetadata: NewFractalTree(),
 		Schema:          make(map[string]string),
 		DataDir:         dataDir,
+		ColumnIndexes:   make(map[string]*DatabaseIndex),
 	}
 }
 
@@ -259,8 +551,14 @@ func (dt *DatabaseTable) InsertData(data map[string][]string) error {
 	return nil
 }
 
-// QueryData queries data from the table
-func (dt *DatabaseTable) QueryData(conditions map[string]string) (arrow.Table, error) {
+// QueryData queries data from the table, scanning each backing Parquet
+// file one row group at a time instead of reading the whole file
+// upfront: rowGroupsSatisfiable first rules out row groups whose
+// column-chunk min/max statistics can't satisfy preds, then every
+// surviving row group's arrow.Record batches are narrowed to a boolean
+// selection vector by evalPredicateVectorized before a single row is
+// copied into the result (see database_vectorized.go).
+func (dt *DatabaseTable) QueryData(conditions map[string]string, metrics *PerformanceMetrics) (arrow.Table, error) {
 	fileListStr := dt.FractalMetadata.GetMetadata("files")
 	if fileListStr == "" {
 		return nil, nil
@@ -268,9 +566,22 @@ func (dt *DatabaseTable) QueryData(conditions map[string]string) (arrow.Table, e
 
 	fileList := strings.Split(fileListStr, ",")
 	alloc := memory.NewGoAllocator()
+	preds := parsePredicates(conditions)
 
-	var combinedTable arrow.Table
-	first := true
+	for _, p := range preds {
+		index, hasIndex := dt.ColumnIndexes[p.Column]
+		if !hasIndex || index.IndexType != "btree" || !p.isRangeShaped() {
+			continue
+		}
+		if lo, hi, ok := p.btreeRange(); ok {
+			hits := index.BTreeIndex.RangeQuery(lo, hi)
+			fmt.Printf("B+ tree range scan on %s.%s (%s) narrowed to %d candidate row(s)\n", dt.Name, p.Column, p.Op, len(hits))
+		}
+	}
+
+	var schema *arrow.Schema
+	var builders []array.Builder
+	numRows := int64(0)
 
 	for _, filename := range fileList {
 		if filename == "" {
@@ -282,43 +593,93 @@ func (dt *DatabaseTable) QueryData(conditions map[string]string) (arrow.Table, e
 			continue
 		}
 
-		// Create Parquet reader
 		reader, err := file.NewParquetReader(f)
 		if err != nil {
 			f.Close()
 			continue
 		}
 
-		// Create Arrow reader
 		arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, alloc)
-		f.Close()
 		if err != nil {
 			reader.Close()
+			f.Close()
 			continue
 		}
 
-		table, err := arrowReader.ReadTable(context.Background())
-		reader.Close()
+		fileSchema, err := arrowReader.Schema()
 		if err != nil {
+			reader.Close()
+			f.Close()
 			continue
 		}
+		if schema == nil {
+			schema = fileSchema
+			builders = newFilteredTableBuilders(alloc, schema)
+			defer func() {
+				for _, b := range builders {
+					b.Release()
+				}
+			}()
+		}
+
+		numRowGroups := reader.NumRowGroups()
+		keepRowGroups := rowGroupsSatisfiable(reader, fileSchema, preds)
+		if metrics != nil {
+			metrics.RecordRowGroupsPruned(numRowGroups - len(keepRowGroups))
+		}
 
-		if first {
-			combinedTable = table
-			first = false
-		} else {
-			// Simple concatenation - in real implementation would need proper table concatenation
-			table.Release()
+		if len(keepRowGroups) > 0 {
+			recordReader, err := arrowReader.GetRecordReader(context.Background(), nil, keepRowGroups)
+			if err == nil {
+				for recordReader.Next() {
+					numRows += appendMatchingRows(recordReader.Record(), preds, builders)
+				}
+				recordReader.Release()
+			}
 		}
+
+		reader.Close()
+		f.Close()
 	}
 
-	if combinedTable == nil {
+	if schema == nil {
 		return nil, nil
 	}
 
-	// Apply conditions (simplified - would need proper filtering)
-	defer combinedTable.Release()
-	return combinedTable, nil
+	return buildFilteredTable(schema, builders, numRows), nil
+}
+
+// cellString renders one row's value for an Arrow column as a string,
+// the common type Predicate.matches compares against regardless of the
+// column's underlying Arrow type.
+func cellString(col arrow.Array, row int) string {
+	if col.IsNull(row) {
+		return ""
+	}
+	switch c := col.(type) {
+	case *array.Int64:
+		return strconv.FormatInt(c.Value(row), 10)
+	case *array.String:
+		return c.Value(row)
+	case *array.Boolean:
+		return strconv.FormatBool(c.Value(row))
+	default:
+		return ""
+	}
+}
+
+// appendCell copies one row of col into builder, preserving nulls.
+func appendCell(builder array.Builder, typeID arrow.Type, col arrow.Array, row int) {
+	if col.IsNull(row) {
+		builder.AppendNull()
+		return
+	}
+	switch typeID {
+	case arrow.INT64:
+		builder.(*array.Int64Builder).Append(col.(*array.Int64).Value(row))
+	default:
+		builder.(*array.StringBuilder).Append(col.(*array.String).Value(row))
+	}
 }
 
 // GetTableInfo returns table information
@@ -339,17 +700,27 @@ type DatabaseSystem struct {
 	Optimizer *QueryOptimizer
 	Metrics   *PerformanceMetrics
 	DataDir   string
+	// LastPlan and LastPlanActualRows record the most recently executed
+	// query's cheapest-estimated index plan and how many rows it
+	// actually returned, so GetDatabaseStats can surface estimated vs.
+	// actual for diagnosing a stale or skewed ColumnStats histogram.
+	LastPlan           *IndexPlan
+	LastPlanActualRows int
+	// Migrations holds every Migration registered per table name, in
+	// registration order (not necessarily ID order - see Migrate).
+	Migrations map[string][]*Migration
 }
 
 // NewDatabaseSystem creates a new database system
 func NewDatabaseSystem(name, dataDir string) *DatabaseSystem {
 	return &DatabaseSystem{
-		Name:      name,
-		Tables:    make(map[string]*DatabaseTable),
-		Indexes:   make([]*DatabaseIndex, 0),
-		Optimizer: NewQueryOptimizer(),
-		Metrics:   NewPerformanceMetrics(),
-		DataDir:   dataDir,
+		Name:       name,
+		Tables:     make(map[string]*DatabaseTable),
+		Indexes:    make([]*DatabaseIndex, 0),
+		Optimizer:  NewQueryOptimizer(),
+		Metrics:    NewPerformanceMetrics(),
+		DataDir:    dataDir,
+		Migrations: make(map[string][]*Migration),
 	}
 }
 
@@ -372,6 +743,7 @@ func (ds *DatabaseSystem) InsertIntoTable(tableName string, data map[string][]st
 
 		// Update indexes
 		ds.updateIndexes(tableName, data)
+		ds.Optimizer.RecordInsert(tableName, data)
 	}
 }
 
@@ -385,45 +757,71 @@ func (ds *DatabaseSystem) CreateIndex(tableName, columnName, indexType string) {
 	index := NewDatabaseIndex(tableName, columnName, indexType)
 	ds.Indexes = append(ds.Indexes, index)
 	ds.Optimizer.AddIndex(index)
+	ds.Tables[tableName].ColumnIndexes[columnName] = index
 
 	fmt.Printf("Created %s index on %s.%s\n", indexType, tableName, columnName)
 }
 
 // QueryTable queries a table with conditions
 func (ds *DatabaseSystem) QueryTable(tableName string, conditions map[string]string) (arrow.Table, error) {
-	if _, exists := ds.Tables[tableName]; !exists {
+	table, exists := ds.Tables[tableName]
+	if !exists {
 		return nil, fmt.Errorf("table %s does not exist", tableName)
 	}
 
 	startTime := time.Now().UnixMicro()
 
-	// Check for applicable indexes
-	applicableIndexes := ds.Optimizer.OptimizeQuery(tableName, conditions)
-	if len(applicableIndexes) > 0 {
-		fmt.Printf("Using indexes: %v\n", applicableIndexes)
+	// Cost every applicable index and note the cheapest-estimated plan;
+	// QueryData itself still does the actual scan regardless of which
+	// index "wins" here, since this simulation doesn't wire the chosen
+	// plan into a distinct execution path.
+	realtimeRowCount := len(table.BTreeIndex.Root.Entries)
+	plans := ds.Optimizer.OptimizeQuery(tableName, conditions, realtimeRowCount)
+	var chosen *IndexPlan
+	if len(plans) > 0 {
+		chosen = &plans[0]
+		fmt.Printf("Candidate indexes by estimated rows scanned: %v\n", plans)
+		fmt.Printf("Using index: %s (est. %d rows)\n", chosen.Label(), chosen.EstRows)
 	}
 
-	result, err := ds.Tables[tableName].QueryData(conditions)
+	result, err := table.QueryData(conditions, ds.Metrics)
 
 	endTime := time.Now().UnixMicro()
 	ds.Metrics.RecordQueryTime(endTime - startTime)
 
+	ds.LastPlan = chosen
+	ds.LastPlanActualRows = 0
+	if result != nil {
+		ds.LastPlanActualRows = int(result.NumRows())
+	}
+
 	return result, err
 }
 
-// updateIndexes updates indexes after data insertion
+// updateIndexes updates indexes after data insertion. A btree index is
+// keyed by the column's own (numeric) value rather than row id, so
+// Predicate.btreeRange's range queries actually narrow on that value; a
+// non-numeric value is skipped since BPlusTree keys are int-only.
 func (ds *DatabaseSystem) updateIndexes(tableName string, data map[string][]string) {
 	for _, index := range ds.Indexes {
-		if index.TableName == tableName {
-			if values, exists := data[index.ColumnName]; exists {
-				// Simplified index update
-				for i, _ := range values {
-					rowID := len(ds.Tables[tableName].BTreeIndex.Root.Entries) + i + 1
-					if index.IndexType == "btree" {
-						index.BTreeIndex.Insert(rowID, fmt.Sprintf("%s_data.parquet:%d", tableName, rowID-1))
-					}
-				}
+		if index.TableName != tableName {
+			continue
+		}
+		values, exists := data[index.ColumnName]
+		if !exists {
+			continue
+		}
+		baseRowID := len(ds.Tables[tableName].BTreeIndex.Root.Entries)
+		for i, v := range values {
+			if index.IndexType != "btree" {
+				continue
 			}
+			rowID := baseRowID + i + 1
+			key, err := strconv.Atoi(v)
+			if err != nil {
+				continue
+			}
+			index.BTreeIndex.Insert(key, fmt.Sprintf("%s_data.parquet:%d", tableName, rowID-1))
 		}
 	}
 }
@@ -447,6 +845,12 @@ func (ds *DatabaseSystem) GetDatabaseStats() map[string]string {
 	}
 	stats["total_rows"] = strconv.Itoa(totalRows)
 
+	if ds.LastPlan != nil {
+		stats["last_plan_index"] = ds.LastPlan.Label()
+		stats["last_plan_estimated_rows"] = strconv.Itoa(ds.LastPlan.EstRows)
+		stats["last_plan_actual_rows"] = strconv.Itoa(ds.LastPlanActualRows)
+	}
+
 	return stats
 }
 
@@ -546,6 +950,27 @@ func demoComprehensiveDatabase() {
 		allOrders.Release()
 	}
 
+	// Query orders over $100, via a post-read filter on total_amount
+	// (unindexed, so no index plan applies)
+	bigOrdersConditions := map[string]string{"total_amount__gt": "100"}
+	bigOrders, err := db.QueryTable("orders", bigOrdersConditions)
+	if err != nil {
+		log.Printf("Error querying big orders: %v", err)
+	} else if bigOrders != nil {
+		fmt.Printf("Orders over $100: %d\n", bigOrders.NumRows())
+		bigOrders.Release()
+	}
+
+	// Query users named alice or diana
+	nameConditions := map[string]string{"username__in": "alice,diana"}
+	namedUsers, err := db.QueryTable("users", nameConditions)
+	if err != nil {
+		log.Printf("Error querying named users: %v", err)
+	} else if namedUsers != nil {
+		fmt.Printf("Users named alice or diana: %d\n", namedUsers.NumRows())
+		namedUsers.Release()
+	}
+
 	// Database statistics
 	fmt.Println("\n=== Database Statistics ===")
 	stats := db.GetDatabaseStats()
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// histogramBuckets caps how many equi-depth buckets ColumnStats builds
+// per column - enough resolution for range selectivity estimates
+// without the bucket count itself becoming a significant fraction of
+// the distinct values being bucketed on tiny tables.
+const histogramBuckets = 10
+
+// ColumnStats tracks the statistics QueryOptimizer needs to cost a
+// predicate against one indexed column: row/null counts, an approximate
+// distinct-value count, and an equi-depth histogram of numeric bucket
+// boundaries + per-bucket counts. Values retains every non-null value
+// observed so Observe can rebuild NDV and the histogram from scratch
+// each time, the same "recompute from the full retained set" approach
+// FractalTree.GetMetadata already uses for its own metadata.
+type ColumnStats struct {
+	TableName      string
+	ColumnName     string
+	TotalRowCount  int
+	NullCount      int
+	NotNullCount   int
+	NDV            int
+	HistogramBounds []float64 // len = buckets+1, ascending; nil if column isn't numeric
+	HistogramCounts []int     // len = buckets; bucket i covers [Bounds[i], Bounds[i+1]]
+	Values          []string  // retained non-null values, for histogram rebuilds
+}
+
+// NewColumnStats creates empty statistics for tableName.columnName,
+// populated by the first Observe call.
+func NewColumnStats(tableName, columnName string) *ColumnStats {
+	return &ColumnStats{TableName: tableName, ColumnName: columnName}
+}
+
+// statsKey is the QueryOptimizer.Stats map key for a table+column pair.
+func statsKey(tableName, columnName string) string {
+	return tableName + "." + columnName
+}
+
+// Observe folds one InsertData batch's values for this column into cs,
+// then rebuilds NDV and the equi-depth histogram from the full retained
+// value set.
+func (cs *ColumnStats) Observe(newValues []string) {
+	for _, v := range newValues {
+		cs.TotalRowCount++
+		if v == "" {
+			cs.NullCount++
+			continue
+		}
+		cs.NotNullCount++
+		cs.Values = append(cs.Values, v)
+	}
+	cs.rebuildHistogram()
+}
+
+// rebuildHistogram recomputes NDV over cs.Values and, if every value
+// parses as a float64, an equi-depth histogram over them. A column with
+// any non-numeric value (e.g. a text column) gets NDV only - its
+// EstimateSelectivity falls back to a full-scan estimate for range ops,
+// since a histogram over lexicographic order isn't meaningful here.
+func (cs *ColumnStats) rebuildHistogram() {
+	distinct := make(map[string]struct{}, len(cs.Values))
+	nums := make([]float64, 0, len(cs.Values))
+	numeric := true
+	for _, v := range cs.Values {
+		distinct[v] = struct{}{}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			numeric = false
+			continue
+		}
+		nums = append(nums, f)
+	}
+	cs.NDV = len(distinct)
+
+	if !numeric || len(nums) == 0 {
+		cs.HistogramBounds = nil
+		cs.HistogramCounts = nil
+		return
+	}
+	sort.Float64s(nums)
+
+	buckets := histogramBuckets
+	if buckets > len(nums) {
+		buckets = len(nums)
+	}
+	bounds := make([]float64, buckets+1)
+	counts := make([]int, buckets)
+	bounds[0] = nums[0]
+	for b := 1; b <= buckets; b++ {
+		idx := b*len(nums)/buckets - 1
+		if idx < 0 {
+			idx = 0
+		}
+		bounds[b] = nums[idx]
+	}
+	for _, v := range nums {
+		b := sort.SearchFloat64s(bounds[1:], v)
+		if b >= buckets {
+			b = buckets - 1
+		}
+		counts[b]++
+	}
+
+	cs.HistogramBounds = bounds
+	cs.HistogramCounts = counts
+}
+
+// EstimateSelectivity estimates the fraction of cs's not-null rows that
+// satisfy p: the histogram's bucket overlap for a range-shaped op,
+// 1/NDV for an equality lookup, and a conservative 1.0 (no narrowing)
+// for anything else or when stats can't speak to p at all.
+func (cs *ColumnStats) EstimateSelectivity(p Predicate) float64 {
+	if cs.NotNullCount == 0 {
+		return 1
+	}
+	switch p.Op {
+	case opExact:
+		if cs.NDV == 0 {
+			return 1
+		}
+		return 1 / float64(cs.NDV)
+	case opGt, opGte, opLt, opLte, opBetween:
+		if frac, ok := cs.histogramSelectivity(p); ok {
+			return frac
+		}
+		return 1
+	default:
+		return 1
+	}
+}
+
+// histogramSelectivity estimates the fraction of cs's histogrammed
+// values falling within p's bound(s), by summing each bucket's overlap
+// with [lo, hi] weighted by the fraction of that bucket's width the
+// overlap covers (assuming values are spread evenly within a bucket).
+// ok is false when cs has no histogram or p's value doesn't parse.
+func (cs *ColumnStats) histogramSelectivity(p Predicate) (frac float64, ok bool) {
+	if len(cs.HistogramBounds) == 0 {
+		return 0, false
+	}
+
+	var lo, hi float64
+	switch p.Op {
+	case opGt, opGte:
+		v, pok := parseFloatValue(p.Value)
+		if !pok {
+			return 0, false
+		}
+		lo, hi = v, cs.HistogramBounds[len(cs.HistogramBounds)-1]
+	case opLt, opLte:
+		v, pok := parseFloatValue(p.Value)
+		if !pok {
+			return 0, false
+		}
+		lo, hi = cs.HistogramBounds[0], v
+	case opBetween:
+		parts := strings.SplitN(p.Value, ",", 2)
+		if len(parts) != 2 {
+			return 0, false
+		}
+		loV, loOK := parseFloatValue(parts[0])
+		hiV, hiOK := parseFloatValue(parts[1])
+		if !loOK || !hiOK {
+			return 0, false
+		}
+		lo, hi = loV, hiV
+	default:
+		return 0, false
+	}
+
+	total, overlap := 0, 0.0
+	for i, count := range cs.HistogramCounts {
+		total += count
+		bucketLo, bucketHi := cs.HistogramBounds[i], cs.HistogramBounds[i+1]
+		width := bucketHi - bucketLo
+		if width <= 0 {
+			if bucketLo >= lo && bucketLo <= hi {
+				overlap += float64(count)
+			}
+			continue
+		}
+		overlapLo, overlapHi := math.Max(bucketLo, lo), math.Min(bucketHi, hi)
+		if overlapHi > overlapLo {
+			overlap += float64(count) * (overlapHi - overlapLo) / width
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return overlap / float64(total), true
+}
+
+// parseFloatValue parses s as a float64, trimming surrounding
+// whitespace the way Predicate.btreeRange's int parsing does.
+func parseFloatValue(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil
+}
+
+// IndexPlan is one index QueryOptimizer judged applicable to a query,
+// along with its estimated row count - the cost signal OptimizeQuery
+// sorts candidates by and GetDatabaseStats later compares against the
+// actual row count the query returned.
+type IndexPlan struct {
+	IndexType  string
+	ColumnName string
+	EstRows    int
+}
+
+// Label mirrors the "indextype_column" string OptimizeQuery used to
+// return before cost-based ranking, kept as the human-readable name for
+// logging and GetDatabaseStats.
+func (p IndexPlan) Label() string {
+	return p.IndexType + "_" + p.ColumnName
+}
+
+// estimateRows turns stats' selectivity estimate for p into a row
+// count: selectivity * TotalRowCount, scaled by increaseFactor to
+// account for rows inserted since stats were last refreshed, then
+// clamped to [1, max(TotalRowCount, realtimeRowCount)] - never to a
+// floor of 0. A zero
+// estimate would make the planner treat a predicate as matching
+// nothing and pick an absurd nested-loop order whenever stats are
+// merely stale; the floor of 1 keeps the plan shape stable instead.
+func estimateRows(stats *ColumnStats, p Predicate, realtimeRowCount int) int {
+	if stats == nil || stats.TotalRowCount == 0 {
+		if realtimeRowCount <= 0 {
+			return 1
+		}
+		return realtimeRowCount
+	}
+
+	selectivity := stats.EstimateSelectivity(p)
+	increaseFactor := float64(realtimeRowCount) / float64(stats.TotalRowCount)
+	if increaseFactor <= 0 {
+		increaseFactor = 1
+	}
+
+	total := stats.TotalRowCount
+	if realtimeRowCount > total {
+		total = realtimeRowCount
+	}
+
+	rows := int(math.Round(selectivity * float64(stats.TotalRowCount) * increaseFactor))
+	if rows < 1 {
+		rows = 1
+	}
+	if rows > total {
+		rows = total
+	}
+	return rows
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/metadata"
+)
+
+// selVector is a per-batch boolean selection vector: index i is true iff
+// row i has survived every predicate evaluated against it so far. Each
+// kernel below only ever turns entries off, so predicates can be applied
+// one at a time without re-scanning rows that are already excluded.
+type selVector []bool
+
+// newSelVector returns a selection vector of length n with every row
+// selected, the starting point before any predicate has narrowed it.
+func newSelVector(n int) selVector {
+	sel := make(selVector, n)
+	for i := range sel {
+		sel[i] = true
+	}
+	return sel
+}
+
+// rowGroupsSatisfiable returns the indices of reader's row groups whose
+// column-chunk min/max statistics cannot be ruled out by every
+// range-shaped predicate in preds - the Parquet analogue of a B+ tree
+// index: a row group whose price column maxes out at 40 can never
+// satisfy "price__gt=100", so it's skipped without reading a single row.
+// A predicate with no statistics available, or that isn't range-shaped,
+// never rules a row group out (the caller still applies it row-by-row).
+func rowGroupsSatisfiable(reader *file.Reader, schema *arrow.Schema, preds []Predicate) []int {
+	fileMeta := reader.MetaData()
+	numRowGroups := reader.NumRowGroups()
+
+	keep := make([]int, 0, numRowGroups)
+	for rg := 0; rg < numRowGroups; rg++ {
+		if rowGroupCouldMatch(fileMeta.RowGroup(rg), schema, preds) {
+			keep = append(keep, rg)
+		}
+	}
+	return keep
+}
+
+// rowGroupCouldMatch reports whether rgMeta's column-chunk statistics
+// leave open the possibility that some row in the group satisfies every
+// predicate in preds.
+func rowGroupCouldMatch(rgMeta *metadata.RowGroupMetaData, schema *arrow.Schema, preds []Predicate) bool {
+	for _, p := range preds {
+		if !p.isRangeShaped() {
+			continue
+		}
+		idx := schema.FieldIndices(p.Column)
+		if len(idx) == 0 {
+			continue
+		}
+		colMeta, err := rgMeta.ColumnChunk(idx[0])
+		if err != nil {
+			continue
+		}
+		stats, err := colMeta.Statistics()
+		if err != nil || stats == nil || !stats.HasMinMax() {
+			continue
+		}
+		if !predicateCouldMatchStats(p, stats) {
+			return false
+		}
+	}
+	return true
+}
+
+// predicateCouldMatchStats reports whether range-shaped predicate p
+// could still match some row in a column chunk described by stats,
+// comparing p's bound(s) against the chunk's min/max rather than any
+// individual value. A false return means every row in the chunk
+// provably fails p and the whole row group can be skipped.
+func predicateCouldMatchStats(p Predicate, stats metadata.TypedStatistics) bool {
+	switch s := stats.(type) {
+	case *metadata.Int64Statistics:
+		min, max := s.Min(), s.Max()
+		if p.Op == opBetween {
+			lo, hi, ok := parseInt64Pair(p.Value)
+			return !ok || (max >= lo && min <= hi)
+		}
+		cst, ok := parseInt64(p.Value)
+		if !ok {
+			return true
+		}
+		switch p.Op {
+		case opExact:
+			return cst >= min && cst <= max
+		case opGt:
+			return max > cst
+		case opGte:
+			return max >= cst
+		case opLt:
+			return min < cst
+		case opLte:
+			return min <= cst
+		}
+	case *metadata.ByteArrayStatistics:
+		min, max := string(s.Min()), string(s.Max())
+		// Predicate.matches orders numeric strings numerically, which
+		// byte-wise min/max don't follow, so a numeric bound never prunes.
+		isNumber := func(v string) bool {
+			_, err := strconv.ParseFloat(v, 64)
+			return err == nil
+		}
+		if p.Op == opBetween {
+			parts := strings.SplitN(p.Value, ",", 2)
+			if len(parts) != 2 || isNumber(parts[0]) || isNumber(parts[1]) {
+				return true
+			}
+			return max >= parts[0] && min <= parts[1]
+		}
+		if p.Op != opExact && isNumber(p.Value) {
+			return true
+		}
+		switch p.Op {
+		case opExact:
+			return p.Value >= min && p.Value <= max
+		case opGt:
+			return max > p.Value
+		case opGte:
+			return max >= p.Value
+		case opLt:
+			return min < p.Value
+		case opLte:
+			return min <= p.Value
+		}
+	}
+	return true
+}
+
+// parseInt64 parses s as a base-10 int64, trimming surrounding
+// whitespace the way Predicate.btreeRange's int parsing does.
+func parseInt64(s string) (int64, bool) {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return v, err == nil
+}
+
+// parseInt64Pair parses a "lo,hi" opBetween value as two int64 bounds.
+func parseInt64Pair(value string) (lo, hi int64, ok bool) {
+	parts := strings.SplitN(value, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	lo, loOK := parseInt64(parts[0])
+	hi, hiOK := parseInt64(parts[1])
+	return lo, hi, loOK && hiOK
+}
+
+// evalPredicateVectorized narrows sel in place to rows of rec that also
+// satisfy p, dispatching to the kernel for p's (column type, operator)
+// pair - each kernel walks the column's underlying Arrow buffer in a
+// tight loop, checking the null bitmap as it goes, analogous to how a
+// vectorized execution engine specializes a cast or comparison per type.
+// A predicate whose column isn't in rec leaves sel unchanged; one whose
+// op has no dedicated kernel for the column's type falls back to
+// evalRowFallback.
+func evalPredicateVectorized(rec arrow.Record, p Predicate, sel selVector) {
+	idx := rec.Schema().FieldIndices(p.Column)
+	if len(idx) == 0 {
+		return
+	}
+	col := rec.Column(idx[0])
+
+	switch c := col.(type) {
+	case *array.Int64:
+		if p.Op == opBetween {
+			if lo, hi, ok := parseInt64Pair(p.Value); ok {
+				evalInt64Between(c, lo, hi, sel)
+				return
+			}
+		} else if cst, ok := parseInt64(p.Value); ok {
+			switch p.Op {
+			case opExact:
+				evalInt64Exact(c, cst, sel)
+				return
+			case opGt:
+				evalInt64GT(c, cst, sel)
+				return
+			case opGte:
+				evalInt64GTE(c, cst, sel)
+				return
+			case opLt:
+				evalInt64LT(c, cst, sel)
+				return
+			case opLte:
+				evalInt64LTE(c, cst, sel)
+				return
+			}
+		}
+	case *array.String:
+		switch p.Op {
+		case opExact:
+			evalStringExact(c, p.Value, sel)
+			return
+		case opIExact:
+			evalStringIExact(c, p.Value, sel)
+			return
+		case opContains:
+			evalStringContains(c, p.Value, sel)
+			return
+		case opIContains:
+			evalStringIContains(c, p.Value, sel)
+			return
+		case opStartswith:
+			evalStringStartswith(c, p.Value, sel)
+			return
+		case opIStartswith:
+			evalStringIStartswith(c, p.Value, sel)
+			return
+		case opEndswith:
+			evalStringEndswith(c, p.Value, sel)
+			return
+		case opIEndswith:
+			evalStringIEndswith(c, p.Value, sel)
+			return
+		case opIn:
+			evalStringIn(c, strings.Split(p.Value, ","), sel)
+			return
+		}
+	}
+
+	evalRowFallback(col, p, sel)
+}
+
+// evalRowFallback narrows sel using Predicate.matches against each
+// row's string-rendered value, the path taken for operators (e.g.
+// isnull) that have no dedicated vectorized kernel.
+func evalRowFallback(col arrow.Array, p Predicate, sel selVector) {
+	for i := range sel {
+		if sel[i] && !p.matches(cellString(col, i)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64Exact(col *array.Int64, cst int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) != cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64GT(col *array.Int64, cst int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) <= cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64GTE(col *array.Int64, cst int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) < cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64LT(col *array.Int64, cst int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) >= cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64LTE(col *array.Int64, cst int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) > cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalInt64Between(col *array.Int64, lo, hi int64, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) < lo || col.Value(i) > hi) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringExact(col *array.String, cst string, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || col.Value(i) != cst) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringIExact(col *array.String, cst string, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.EqualFold(col.Value(i), cst)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringContains(col *array.String, pat string, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.Contains(col.Value(i), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringIContains(col *array.String, pat string, sel selVector) {
+	pat = strings.ToLower(pat)
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.Contains(strings.ToLower(col.Value(i)), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringStartswith(col *array.String, pat string, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.HasPrefix(col.Value(i), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringIStartswith(col *array.String, pat string, sel selVector) {
+	pat = strings.ToLower(pat)
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.HasPrefix(strings.ToLower(col.Value(i)), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringEndswith(col *array.String, pat string, sel selVector) {
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.HasSuffix(col.Value(i), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringIEndswith(col *array.String, pat string, sel selVector) {
+	pat = strings.ToLower(pat)
+	for i := range sel {
+		if sel[i] && (col.IsNull(i) || !strings.HasSuffix(strings.ToLower(col.Value(i)), pat)) {
+			sel[i] = false
+		}
+	}
+}
+
+func evalStringIn(col *array.String, values []string, sel selVector) {
+	wanted := make([]string, len(values))
+	for i, v := range values {
+		wanted[i] = strings.TrimSpace(v)
+	}
+	for i := range sel {
+		if !sel[i] {
+			continue
+		}
+		if col.IsNull(i) {
+			sel[i] = false
+			continue
+		}
+		found := false
+		for _, want := range wanted {
+			if col.Value(i) == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sel[i] = false
+		}
+	}
+}
+
+// newFilteredTableBuilders allocates one Arrow builder per field of
+// schema, the accumulators appendMatchingRows copies surviving rows
+// into and buildFilteredTable ultimately drains into a table.
+func newFilteredTableBuilders(alloc memory.Allocator, schema *arrow.Schema) []array.Builder {
+	builders := make([]array.Builder, schema.NumFields())
+	for i, f := range schema.Fields() {
+		if f.Type.ID() == arrow.INT64 {
+			builders[i] = array.NewInt64Builder(alloc)
+		} else {
+			builders[i] = array.NewStringBuilder(alloc)
+		}
+	}
+	return builders
+}
+
+// appendMatchingRows evaluates every predicate in preds against batch as
+// a selection vector (see evalPredicateVectorized) and copies only the
+// surviving rows into builders, returning how many rows were kept. An
+// empty preds selects every row in batch unfiltered.
+func appendMatchingRows(batch arrow.Record, preds []Predicate, builders []array.Builder) int64 {
+	sel := newSelVector(int(batch.NumRows()))
+	for _, p := range preds {
+		evalPredicateVectorized(batch, p, sel)
+	}
+
+	schema := batch.Schema()
+	kept := int64(0)
+	for row := 0; row < len(sel); row++ {
+		if !sel[row] {
+			continue
+		}
+		for i, f := range schema.Fields() {
+			appendCell(builders[i], f.Type.ID(), batch.Column(i), row)
+		}
+		kept++
+	}
+	return kept
+}
+
+// buildFilteredTable drains builders into arrays and assembles them into
+// an arrow.Table with schema and numRows rows.
+func buildFilteredTable(schema *arrow.Schema, builders []array.Builder, numRows int64) arrow.Table {
+	arrs := make([]arrow.Array, len(builders))
+	for i, b := range builders {
+		arrs[i] = b.NewArray()
+	}
+	defer func() {
+		for _, a := range arrs {
+			a.Release()
+		}
+	}()
+
+	cols := make([]arrow.Column, len(arrs))
+	for i, a := range arrs {
+		chunked := arrow.NewChunked(schema.Field(i).Type, []arrow.Array{a})
+		cols[i] = *arrow.NewColumn(schema.Field(i), chunked)
+		chunked.Release()
+	}
+
+	return array.NewTable(schema, cols, numRows)
+}
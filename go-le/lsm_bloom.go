@@ -0,0 +1,340 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// bloomFilterKVKey and bloomFilterBitsKVKey name the Parquet key-value
+// metadata entries WriteSSTable attaches to a file, so a reader can
+// reconstruct the filter without re-scanning the rows.
+const (
+	bloomFilterKVKey     = "bloom_filter"
+	bloomFilterBitsKVKey = "bloom_num_bits"
+
+	// bloomBitsPerKey and bloomNumHashes are tuned for ~1% false-positive
+	// rate (k = ln(2) * bits/key ~= 6.9, rounded up to 7).
+	bloomBitsPerKey = 10
+	bloomNumHashes  = 7
+)
+
+// bloomFilter is a Bloom filter over SSTable keys, built from two
+// FNV-1a hashes combined via Kirsch-Mitzenmacher double hashing
+// (h_i = h1 + i*h2) instead of computing bloomNumHashes independent
+// hash functions.
+type bloomFilter struct {
+	bits      []byte
+	numBits   uint64
+	numHashes uint32
+}
+
+// newBloomFilter sizes a filter for numKeys entries at bloomBitsPerKey
+// bits/key.
+func newBloomFilter(numKeys int) *bloomFilter {
+	numBits := uint64(numKeys) * bloomBitsPerKey
+	if numBits < 64 {
+		numBits = 64
+	}
+	return &bloomFilter{
+		bits:      make([]byte, (numBits+7)/8),
+		numBits:   numBits,
+		numHashes: bloomNumHashes,
+	}
+}
+
+// fnv1aSeeded hashes key with FNV-1a primed by seed, giving two
+// sufficiently independent base hashes to double-hash from.
+func fnv1aSeeded(key string, seed uint32) uint32 {
+	h := fnv.New32a()
+	var seedBytes [4]byte
+	binary.LittleEndian.PutUint32(seedBytes[:], seed)
+	h.Write(seedBytes[:])
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+func (b *bloomFilter) bitPositions(key string) (h1, h2 uint32) {
+	return fnv1aSeeded(key, 0), fnv1aSeeded(key, 0x9e3779b9)
+}
+
+// Add sets key's bloomNumHashes bits.
+func (b *bloomFilter) Add(key string) {
+	h1, h2 := b.bitPositions(key)
+	for i := uint32(0); i < b.numHashes; i++ {
+		bit := (uint64(h1) + uint64(i)*uint64(h2)) % b.numBits
+		b.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// MayContain reports whether key might be present. false is a definite
+// no; true may be a false positive.
+func (b *bloomFilter) MayContain(key string) bool {
+	h1, h2 := b.bitPositions(key)
+	for i := uint32(0); i < b.numHashes; i++ {
+		bit := (uint64(h1) + uint64(i)*uint64(h2)) % b.numBits
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encode base64-encodes the filter's bitset for storage as a Parquet
+// key-value metadata string.
+func (b *bloomFilter) encode() string {
+	return base64.StdEncoding.EncodeToString(b.bits)
+}
+
+// decodeBloomFilter rebuilds a filter from its encoded bitset and the
+// numBits it was sized with.
+func decodeBloomFilter(encoded string, numBits uint64) (*bloomFilter, error) {
+	bits, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &bloomFilter{bits: bits, numBits: numBits, numHashes: bloomNumHashes}, nil
+}
+
+// attachBloomMetadata appends bloom's encoded bitset to writer's Parquet
+// key-value metadata. pqarrow has no way to set key-value metadata via
+// WriterProperties before the fact - it must be appended to the
+// FileWriter itself, after the row groups are written but before Close -
+// so WriteSSTable calls this instead of threading it through
+// NewWriterProperties.
+func attachBloomMetadata(writer *pqarrow.FileWriter, bloom *bloomFilter) error {
+	if err := writer.AppendKeyValueMetadata(bloomFilterKVKey, bloom.encode()); err != nil {
+		return err
+	}
+	return writer.AppendKeyValueMetadata(bloomFilterBitsKVKey, strconv.FormatUint(bloom.numBits, 10))
+}
+
+// loadBloomFilter opens sst's file and reconstructs its Bloom filter from
+// the key-value metadata WriteSSTable attached to it. loadManifest calls
+// it once per SSTable reloaded from the MANIFEST at startup; a freshly
+// written SSTable gets its filter from WriteSSTable directly. Either way
+// later point reads never touch disk just to prune a miss.
+func loadBloomFilter(filename string) (*bloomFilter, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	kv := reader.MetaData().KeyValueMetadata()
+	if kv == nil {
+		return nil, nil
+	}
+	encoded := kv.FindValue(bloomFilterKVKey)
+	numBitsStr := kv.FindValue(bloomFilterBitsKVKey)
+	if encoded == nil || numBitsStr == nil {
+		return nil, nil
+	}
+	numBits, err := strconv.ParseUint(*numBitsStr, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBloomFilter(*encoded, numBits)
+}
+
+// Contains short-circuits a point read using file-level range pruning
+// and the Bloom filter before anything touches disk. A false return is
+// definitive; true means the key might be in the file and it must be
+// opened to find out.
+func (sst *SSTable) Contains(key string) bool {
+	meta := sst.Metadata
+	if meta == nil {
+		return true
+	}
+	if meta.MinKey != "" && key < meta.MinKey {
+		return false
+	}
+	if meta.MaxKey != "" && key > meta.MaxKey {
+		return false
+	}
+	if meta.bloom != nil && !meta.bloom.MayContain(key) {
+		return false
+	}
+	return true
+}
+
+// sstBlockCacheCapacity bounds the number of decoded row-group batches
+// the process keeps in memory across all SSTables.
+const sstBlockCacheCapacity = 256
+
+// blockCacheKey identifies one decoded row group of one SSTable file.
+type blockCacheKey struct {
+	filename string
+	rowGroup int
+}
+
+// blockCache is a small LRU of decoded Arrow record batches, keyed by
+// (filename, row-group index), so repeated point reads against the same
+// hot SSTable avoid re-decoding Parquet row groups.
+type blockCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[blockCacheKey]*list.Element
+}
+
+type blockCacheEntry struct {
+	key    blockCacheKey
+	record arrow.Record
+}
+
+func newBlockCache(capacity int) *blockCache {
+	return &blockCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[blockCacheKey]*list.Element),
+	}
+}
+
+func (c *blockCache) get(filename string, rowGroup int) (arrow.Record, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := blockCacheKey{filename, rowGroup}
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*blockCacheEntry).record, true
+}
+
+func (c *blockCache) put(filename string, rowGroup int, record arrow.Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := blockCacheKey{filename, rowGroup}
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	record.Retain()
+	el := c.ll.PushFront(&blockCacheEntry{key: key, record: record})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			entry := oldest.Value.(*blockCacheEntry)
+			delete(c.items, entry.key)
+			entry.record.Release()
+		}
+	}
+}
+
+// sstBlockCache is the process-wide block cache shared by every SSTable
+// point lookup.
+var sstBlockCache = newBlockCache(sstBlockCacheCapacity)
+
+// readRowGroup returns row group rg of filename as a single Arrow
+// record, serving it from sstBlockCache when already decoded.
+func readRowGroup(arrowReader *pqarrow.FileReader, filename string, rg int) (arrow.Record, error) {
+	if record, ok := sstBlockCache.get(filename, rg); ok {
+		return record, nil
+	}
+
+	rr, err := arrowReader.GetRecordReader(context.Background(), nil, []int{rg})
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Release()
+
+	if !rr.Next() {
+		if err := rr.Err(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	record := rr.Record()
+	sstBlockCache.put(filename, rg, record)
+	return record, nil
+}
+
+// lookupInSSTable scans sst for key's latest version, row group by row
+// group, using sstBlockCache to skip re-decoding groups already read by
+// an earlier lookup. Callers should check sst.Contains(key) first to
+// avoid opening files the Bloom filter or key range already rule out.
+func lookupInSSTable(sst *SSTable, key string) (value string, deleted bool, found bool) {
+	return lookupInSSTableAt(sst, key, math.MaxInt64)
+}
+
+// lookupInSSTableAt is like lookupInSSTable but restricted to the
+// newest version of key with Seq <= maxSeq, so a snapshot read doesn't
+// see a version written after the snapshot was taken. A key can have
+// more than one row in sst (every version the memtable or a compaction
+// carried forward), so every row group is still scanned even after a
+// match, to find the best (highest eligible Seq) one.
+func lookupInSSTableAt(sst *SSTable, key string, maxSeq int64) (value string, deleted bool, found bool) {
+	if !sst.Contains(key) {
+		return "", false, false
+	}
+
+	f, err := os.Open(sst.Filename)
+	if err != nil {
+		return "", false, false
+	}
+	defer f.Close()
+
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		return "", false, false
+	}
+	defer reader.Close()
+
+	arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return "", false, false
+	}
+
+	bestSeq := int64(-1)
+	for rg := 0; rg < reader.MetaData().NumRowGroups(); rg++ {
+		record, err := readRowGroup(arrowReader, sst.Filename, rg)
+		if err != nil || record == nil {
+			continue
+		}
+
+		keyCol := record.Column(0).(*array.String)
+		valueCol := record.Column(1).(*array.String)
+		deletedCol := record.Column(2).(*array.Boolean)
+		seqCol := record.Column(3).(*array.Int64)
+
+		for i := 0; i < int(record.NumRows()); i++ {
+			if keyCol.Value(i) != key {
+				continue
+			}
+			seq := seqCol.Value(i)
+			if seq > maxSeq || seq <= bestSeq {
+				continue
+			}
+			bestSeq, value, deleted, found = seq, valueCol.Value(i), deletedCol.Value(i), true
+		}
+	}
+
+	return value, deleted, found
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxEntriesPerCompactedFile bounds how many rows a single compaction
+// output file holds, so Li (i>=1) stays split into multiple
+// non-overlapping, binary-searchable files instead of one giant one.
+const maxEntriesPerCompactedFile = 50
+
+// manifestRecord is one append-only MANIFEST line: a file entering or
+// leaving the level layout. Replaying every record in order reconstructs
+// exactly which files are live and which level each belongs to.
+type manifestRecord struct {
+	Action     string `json:"action"` // "add" or "remove"
+	Level      int    `json:"level"`
+	Filename   string `json:"filename"`
+	MinKey     string `json:"min_key"`
+	MaxKey     string `json:"max_key"`
+	NumEntries int64  `json:"num_entries"`
+}
+
+func newManifestAddRecord(sst *SSTable) manifestRecord {
+	return manifestRecord{
+		Action:     "add",
+		Level:      sst.Metadata.Level,
+		Filename:   sst.Filename,
+		MinKey:     sst.Metadata.MinKey,
+		MaxKey:     sst.Metadata.MaxKey,
+		NumEntries: sst.Metadata.NumEntries,
+	}
+}
+
+// comparatorPath returns the path to the file recording which
+// Comparator.Name a database at dataDir was created with.
+func comparatorPath(dataDir string) string {
+	return filepath.Join(dataDir, "COMPARATOR")
+}
+
+// checkComparator pins dataDir to cmp's name the first time it's opened,
+// and on every later open fails fast if cmp's name doesn't match what's
+// already recorded - opening an existing database with a different
+// comparator would silently reorder (and so corrupt reads of) its
+// existing keys otherwise.
+func checkComparator(dataDir string, cmp Comparator) error {
+	path := comparatorPath(dataDir)
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if mkErr := os.MkdirAll(dataDir, 0755); mkErr != nil {
+			return mkErr
+		}
+		return os.WriteFile(path, []byte(cmp.Name()+"\n"), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	if want := strings.TrimSpace(string(existing)); want != cmp.Name() {
+		return fmt.Errorf("comparator mismatch: database at %s was created with %q, got %q", dataDir, want, cmp.Name())
+	}
+	return nil
+}
+
+// currentPath returns the path to CURRENT, the leveldb-style pointer
+// file that names which MANIFEST generation is the live one. Today
+// there's only ever one generation (manifestFilename), but routing every
+// lookup through CURRENT means a future manifest-rotation scheme only
+// has to change writeCurrent/readCurrent, not every MANIFEST callsite.
+func currentPath(dataDir string) string {
+	return filepath.Join(dataDir, "CURRENT")
+}
+
+// manifestFilename is the name CURRENT points at.
+const manifestFilename = "MANIFEST"
+
+// writeCurrent atomically (temp file + rename) points CURRENT at
+// manifestFile, the same durability trick leveldb uses so a crash never
+// leaves CURRENT referencing a manifest that doesn't exist yet.
+func writeCurrent(dataDir, manifestFile string) error {
+	tmp := currentPath(dataDir) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(manifestFile+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, currentPath(dataDir))
+}
+
+// readCurrent returns the MANIFEST generation CURRENT points at,
+// defaulting to manifestFilename if CURRENT hasn't been written yet
+// (a fresh database, or one predating this file's introduction).
+func readCurrent(dataDir string) string {
+	data, err := os.ReadFile(currentPath(dataDir))
+	if err != nil {
+		return manifestFilename
+	}
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return manifestFilename
+	}
+	return name
+}
+
+func manifestPath(dataDir string) string {
+	return filepath.Join(dataDir, readCurrent(dataDir))
+}
+
+// appendManifestRecord appends one JSON record to the MANIFEST file,
+// pointing CURRENT at it first if this is the first record written (a
+// fresh database with no CURRENT file yet).
+func appendManifestRecord(dataDir string, rec manifestRecord) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(currentPath(dataDir)); os.IsNotExist(err) {
+		if err := writeCurrent(dataDir, manifestFilename); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(manifestPath(dataDir), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(string(data) + "\n")
+	return err
+}
+
+// loadManifest replays the MANIFEST's add/remove records into lsm.Levels,
+// so a restart reconstructs the exact level layout that survived
+// compaction instead of guessing levels from filenames.
+func (lsm *LSMTree) loadManifest() {
+	f, err := os.Open(manifestPath(lsm.Config.DataDir))
+	if err != nil {
+		return // no manifest yet; starting fresh
+	}
+	defer f.Close()
+
+	live := make(map[string]*SSTable)
+	var order []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var rec manifestRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			log.Printf("Skipping malformed MANIFEST record: %v", err)
+			continue
+		}
+
+		switch rec.Action {
+		case "add":
+			sst := NewSSTable(rec.Filename)
+			sst.Metadata.Level = rec.Level
+			sst.Metadata.MinKey = rec.MinKey
+			sst.Metadata.MaxKey = rec.MaxKey
+			sst.Metadata.NumEntries = rec.NumEntries
+			live[rec.Filename] = sst
+			order = append(order, rec.Filename)
+		case "remove":
+			delete(live, rec.Filename)
+		}
+	}
+
+	// Place files in MANIFEST order, so L0 keeps its oldest-to-newest
+	// order that Get and compaction rely on.
+	for _, name := range order {
+		sst, ok := live[name]
+		if !ok {
+			continue
+		}
+		delete(live, name)
+		if bloom, err := loadBloomFilter(sst.Filename); err != nil {
+			log.Printf("Error loading Bloom filter for %s: %v", sst.Filename, err)
+		} else {
+			sst.Metadata.bloom = bloom
+		}
+		lsm.placeInLevel(sst, sst.Metadata.Level)
+	}
+	for level := 1; level < len(lsm.Levels); level++ {
+		lsm.sortLevel(level)
+	}
+}
+
+// placeInLevel appends sst to level, growing lsm.Levels as needed.
+func (lsm *LSMTree) placeInLevel(sst *SSTable, level int) {
+	for len(lsm.Levels) <= level {
+		lsm.Levels = append(lsm.Levels, nil)
+	}
+	lsm.Levels[level] = append(lsm.Levels[level], sst)
+}
+
+// sortLevel orders a non-overlapping level's files by MinKey so Get can
+// binary-search it.
+func (lsm *LSMTree) sortLevel(level int) {
+	files := lsm.Levels[level]
+	sort.Slice(files, func(i, j int) bool { return files[i].Metadata.MinKey < files[j].Metadata.MinKey })
+}
+
+// tableCompactionLoop checks every level against its threshold/budget the
+// moment memtableCompactionLoop nudges tcompCmdC after a flush lands a
+// new L0 file, and additionally on lsm.Config.CompactionCheckInterval
+// when EnableBackgroundCompaction is set, until lsm.stopCompaction is
+// closed. With EnableBackgroundCompaction off, tickC is left nil, so that
+// case simply never fires and compaction runs purely flush-triggered.
+func (lsm *LSMTree) tableCompactionLoop() {
+	var tickC <-chan time.Time
+	if lsm.Config.EnableBackgroundCompaction {
+		ticker := time.NewTicker(lsm.Config.CompactionCheckInterval)
+		defer ticker.Stop()
+		tickC = ticker.C
+	}
+
+	for {
+		select {
+		case <-tickC:
+			lsm.compactIfNeeded()
+		case <-lsm.tcompCmdC:
+			lsm.compactIfNeeded()
+		case <-lsm.stopCompaction:
+			return
+		}
+	}
+}
+
+// compactIfNeeded takes the tree's write lock and runs one compaction
+// pass. Used by the background compactor, which doesn't already hold it.
+func (lsm *LSMTree) compactIfNeeded() {
+	lsm.mutex.Lock()
+	defer lsm.mutex.Unlock()
+	lsm.compactIfNeededLocked()
+}
+
+// compactIfNeededLocked compacts L0 once it reaches L0CompactionThreshold
+// files, then compacts any level whose total size exceeds its budget.
+// Callers must already hold lsm.mutex for writing.
+func (lsm *LSMTree) compactIfNeededLocked() {
+	if len(lsm.Levels) > 0 && len(lsm.Levels[0]) >= lsm.Config.L0CompactionThreshold {
+		lsm.compactLevel(0)
+	}
+	for level := 1; level < len(lsm.Levels); level++ {
+		if lsm.levelSizeBytes(level) > lsm.levelBudget(level) {
+			lsm.compactLevel(level)
+		}
+	}
+}
+
+// levelSizeBytes sums the on-disk size of every file in level.
+func (lsm *LSMTree) levelSizeBytes(level int) int64 {
+	var total int64
+	for _, sst := range lsm.Levels[level] {
+		total += sst.Metadata.FileSize
+	}
+	return total
+}
+
+// levelBudget returns Li's size budget: BaseLevelSizeBytes for L1, scaled
+// by LevelSizeMultiplier for each level below that.
+func (lsm *LSMTree) levelBudget(level int) int64 {
+	budget := lsm.Config.BaseLevelSizeBytes
+	for i := 1; i < level; i++ {
+		budget *= int64(lsm.Config.LevelSizeMultiplier)
+	}
+	return budget
+}
+
+// compactLevel compacts level i forward into i+1. L0 (i==0) files may
+// overlap, so every L0 file participates; Li (i>=1) is non-overlapping,
+// so a single file is picked. The files in i+1 whose key range overlaps
+// the input range are merged in too, via a k-way merge that resolves
+// duplicate keys newest-input-wins, and the result is rewritten as new,
+// non-overlapping SSTables at i+1.
+func (lsm *LSMTree) compactLevel(i int) {
+	var inputs []*SSTable
+	if i == 0 {
+		inputs = append(inputs, lsm.Levels[0]...)
+	} else if len(lsm.Levels[i]) > 0 {
+		inputs = append(inputs, lsm.Levels[i][0])
+	}
+	if len(inputs) == 0 {
+		return
+	}
+
+	target := i + 1
+	for len(lsm.Levels) <= target {
+		lsm.Levels = append(lsm.Levels, nil)
+	}
+
+	lo, hi := compactionKeyRange(inputs)
+	var overlapping []*SSTable
+	for _, sst := range lsm.Levels[target] {
+		if rangesOverlap(sst.Metadata.MinKey, sst.Metadata.MaxKey, lo, hi) {
+			overlapping = append(overlapping, sst)
+		}
+	}
+
+	// Rank inputs newest-first (later L0 files are newer; an Li file is
+	// always newer than anything already at Li+1), so the k-way merge
+	// resolves duplicate keys in favor of the more recent write.
+	sources := make([]iterSource, 0, len(inputs)+len(overlapping))
+	rank := 0
+	for idx := len(inputs) - 1; idx >= 0; idx-- {
+		sources = append(sources, newSSTSource(inputs[idx], rank))
+		rank++
+	}
+	for _, sst := range overlapping {
+		sources = append(sources, newSSTSource(sst, rank))
+		rank++
+	}
+
+	// A tombstone can only be safely dropped once no lower level could
+	// still hold (and need to be shadowed by) the same key; this is
+	// simplified to "no level below target currently has any files".
+	dropTombstones := true
+	for level := target + 1; level < len(lsm.Levels); level++ {
+		if len(lsm.Levels[level]) > 0 {
+			dropTombstones = false
+			break
+		}
+	}
+
+	watermark := lsm.gcWatermark()
+	merged, err := mergeSSTableSources(sources, watermark, dropTombstones)
+	if err != nil {
+		log.Printf("Compaction L%d->L%d failed: %v", i, target, err)
+		return
+	}
+
+	newFiles, err := writeLeveledSSTables(merged, target, lsm.Config.DataDir)
+	if err != nil {
+		log.Printf("Compaction L%d->L%d failed to write output: %v", i, target, err)
+		return
+	}
+
+	removed := append(append([]*SSTable{}, inputs...), overlapping...)
+	for _, sst := range removed {
+		if err := os.Remove(sst.Filename); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: failed to remove compacted file %s: %v", sst.Filename, err)
+		}
+		if err := appendManifestRecord(lsm.Config.DataDir, manifestRecord{Action: "remove", Filename: sst.Filename}); err != nil {
+			log.Printf("Error appending MANIFEST remove record: %v", err)
+		}
+	}
+
+	lsm.Levels[i] = removeSSTables(lsm.Levels[i], inputs)
+	lsm.Levels[target] = removeSSTables(lsm.Levels[target], overlapping)
+
+	for _, sst := range newFiles {
+		lsm.placeInLevel(sst, target)
+		if err := appendManifestRecord(lsm.Config.DataDir, newManifestAddRecord(sst)); err != nil {
+			log.Printf("Error appending MANIFEST add record: %v", err)
+		}
+	}
+	lsm.sortLevel(target)
+}
+
+// compactionKeyRange returns the smallest MinKey and largest MaxKey
+// spanned by inputs.
+func compactionKeyRange(inputs []*SSTable) (string, string) {
+	lo, hi := inputs[0].Metadata.MinKey, inputs[0].Metadata.MaxKey
+	for _, sst := range inputs[1:] {
+		if sst.Metadata.MinKey < lo {
+			lo = sst.Metadata.MinKey
+		}
+		if sst.Metadata.MaxKey > hi {
+			hi = sst.Metadata.MaxKey
+		}
+	}
+	return lo, hi
+}
+
+func rangesOverlap(minKey, maxKey, lo, hi string) bool {
+	return minKey <= hi && maxKey >= lo
+}
+
+// removeSSTables returns level with every file in remove filtered out.
+func removeSSTables(level []*SSTable, remove []*SSTable) []*SSTable {
+	removeSet := make(map[string]bool, len(remove))
+	for _, sst := range remove {
+		removeSet[sst.Filename] = true
+	}
+
+	var kept []*SSTable
+	for _, sst := range level {
+		if !removeSet[sst.Filename] {
+			kept = append(kept, sst)
+		}
+	}
+	return kept
+}
+
+// writeLeveledSSTables splits a sorted batch of merged entries into
+// maxEntriesPerCompactedFile-sized chunks and writes each as its own,
+// non-overlapping SSTable at level.
+func writeLeveledSSTables(entries []SSTableEntry, level int, dataDir string) ([]*SSTable, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var files []*SSTable
+	for start := 0; start < len(entries); start += maxEntriesPerCompactedFile {
+		end := start + maxEntriesPerCompactedFile
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		filename := filepath.Join(dataDir, fmt.Sprintf("sstable_L%d_%d_%d.parquet", level, time.Now().UnixNano(), start))
+		sst, err := WriteSSTable(entries[start:end], filename, level)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sst)
+	}
+	return files, nil
+}
+
+// mergedVersion is one surviving version of a key popped off the merge
+// heap, before the watermark-based collapse in popMerged decides what
+// to keep.
+type mergedVersion struct {
+	value   string
+	deleted bool
+	seq     int64
+}
+
+// popMerged pops every version of the smallest key off h and applies
+// the MVCC GC rule: any version with seq > watermark is kept as-is
+// (a live snapshot newer than watermark may still need it), and of the
+// versions with seq <= watermark only the newest survives, since no
+// live snapshot's floor read can be older than watermark. Every source
+// sharing the key is advanced so it doesn't reappear in a later pop.
+// Returned versions are ascending by seq; hasFloor reports whether
+// kept[0] is that collapsed floor version (as opposed to just being the
+// lowest-seq version still above watermark).
+func popMerged(h *sourceHeap, watermark int64) (key string, kept []mergedVersion, hasFloor bool, err error) {
+	key = (*h)[0].key()
+
+	var versions []mergedVersion
+	for h.Len() > 0 && (*h)[0].key() == key {
+		src := heap.Pop(h).(iterSource)
+		v, deleted := src.value()
+		versions = append(versions, mergedVersion{value: v, deleted: deleted, seq: src.seq()})
+		if err := src.advance(); err != nil {
+			return "", nil, false, err
+		}
+		if src.valid() {
+			heap.Push(h, src)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].seq < versions[j].seq })
+
+	var floor *mergedVersion
+	for i := range versions {
+		v := versions[i]
+		if v.seq <= watermark {
+			floor = &versions[i]
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if floor != nil {
+		kept = append([]mergedVersion{*floor}, kept...)
+		hasFloor = true
+	}
+
+	return key, kept, hasFloor, nil
+}
+
+// mergeSSTableSources runs a k-way min-heap merge over sources (ranked
+// newest-first) and returns the merged, sorted entries. watermark is the
+// GC watermark (the smallest active snapshot sequence, or
+// math.MaxInt64 with none live): versions at or below it are collapsed
+// to the newest one, since no live snapshot can see further back than
+// that. When dropTombstones is true, a key's floor version is dropped
+// from the output if it's a tombstone, rather than carried forward,
+// since nothing below this level could still need it to shadow a value.
+func mergeSSTableSources(sources []iterSource, watermark int64, dropTombstones bool) ([]SSTableEntry, error) {
+	for _, src := range sources {
+		if err := src.open(); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		for _, src := range sources {
+			src.close()
+		}
+	}()
+
+	h := make(sourceHeap, 0, len(sources))
+	for _, src := range sources {
+		if src.valid() {
+			h = append(h, src)
+		}
+	}
+	heap.Init(&h)
+
+	var out []SSTableEntry
+	for h.Len() > 0 {
+		key, versions, hasFloor, err := popMerged(&h, watermark)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range versions {
+			if hasFloor && i == 0 && v.deleted && dropTombstones {
+				continue
+			}
+			out = append(out, SSTableEntry{Key: key, Value: v.value, Deleted: v.deleted, Seq: v.seq})
+		}
+	}
+	return out, nil
+}
+
+// lookupInSSTable (sst.Contains pruning plus the row-group block cache)
+// lives in lsm_bloom.go alongside the Bloom filter it relies on.
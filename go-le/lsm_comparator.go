@@ -0,0 +1,83 @@
+package main
+
+import "bytes"
+
+// Comparator defines the key ordering an LSM tree is built over. It's
+// modeled on leveldb's comparator: Compare gives the ordering itself,
+// while Separator and Successor pick a short key that still preserves
+// that ordering (e.g. "g" instead of "google" as a dividing key). This
+// package's compaction does not call Separator or Successor: SSTable
+// boundaries are recorded as full first/last keys. Name is persisted alongside a database's
+// MANIFEST so opening it later with a different comparator fails fast
+// instead of silently reordering (and corrupting reads of) existing data.
+type Comparator interface {
+	// Name identifies the comparator for persistence and mismatch
+	// detection. Changing what Compare/Separator/Successor do for an
+	// existing name breaks any database already written with it, so
+	// implementations should version the name (e.g. "myapp.v2") the same
+	// way leveldb does rather than reusing "leveldb.BytewiseComparator".
+	Name() string
+	// Compare returns <0, 0, or >0 as a sorts before, equals, or sorts
+	// after b.
+	Compare(a, b []byte) int
+	// Separator returns a key >= a and < b that's no longer than the
+	// longer of the two, suitable as a short dividing key between them.
+	// It may just return a unchanged if no shorter separator exists.
+	Separator(a, b []byte) []byte
+	// Successor returns a key >= a, ideally short, that's still a valid
+	// upper bound for anything prefixed by a. It may just return a
+	// unchanged if no shorter successor exists.
+	Successor(a []byte) []byte
+}
+
+// BytewiseComparator orders keys by raw byte value, the same ordering
+// Go's string comparison and this package's existing sort.Slice/
+// sort.Search calls already assumed before Comparator existed. It's the
+// default for every LSMTreeConfig.
+type BytewiseComparator struct{}
+
+func (BytewiseComparator) Name() string { return "bytewise" }
+
+func (BytewiseComparator) Compare(a, b []byte) int { return bytes.Compare(a, b) }
+
+// Separator finds the first byte at which a and b differ and, if it can
+// be incremented without reordering past b, truncates there - mirroring
+// leveldb's BytewiseComparator::FindShortestSeparator.
+func (c BytewiseComparator) Separator(a, b []byte) []byte {
+	minLen := len(a)
+	if len(b) < minLen {
+		minLen = len(b)
+	}
+	diff := 0
+	for diff < minLen && a[diff] == b[diff] {
+		diff++
+	}
+	if diff >= minLen {
+		return a // one is a prefix of the other; no shorter separator
+	}
+	if a[diff] < 0xff && a[diff]+1 < orZero(b, diff) {
+		shortened := append(append([]byte{}, a[:diff]...), a[diff]+1)
+		return shortened
+	}
+	return a
+}
+
+func orZero(b []byte, i int) byte {
+	if i < len(b) {
+		return b[i]
+	}
+	return 0
+}
+
+// Successor truncates a to its first byte that can be incremented
+// without becoming longer than a, mirroring leveldb's
+// BytewiseComparator::FindShortSuccessor.
+func (c BytewiseComparator) Successor(a []byte) []byte {
+	for i, v := range a {
+		if v != 0xff {
+			successor := append(append([]byte{}, a[:i]...), v+1)
+			return successor
+		}
+	}
+	return a // every byte is 0xff; no shorter successor
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
-	"bufio"
+	"container/list"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
@@ -72,6 +76,30 @@ type LSMTreeConfig struct {
 	DataDir                    string
 	EnableBackgroundCompaction bool
 	CompactionCheckInterval    time.Duration
+	// L0CompactionThreshold is how many (possibly overlapping) L0 files
+	// accumulate before they're compacted into L1.
+	L0CompactionThreshold int
+	// LevelSizeMultiplier is how much larger each level's size budget is
+	// than the level above it (L(i+1) budget = budget(i) * multiplier).
+	LevelSizeMultiplier int
+	// BaseLevelSizeBytes is L1's size budget.
+	BaseLevelSizeBytes int64
+	// L0SlowdownWritesTrigger is the L0 file count past which Put starts
+	// sleeping briefly before each write, giving the compactor room to
+	// catch up instead of letting L0 grow without bound.
+	L0SlowdownWritesTrigger int
+	// L0StopWritesTrigger is the L0 file count past which Put blocks
+	// outright until compaction brings L0 back down.
+	L0StopWritesTrigger int
+	// Comparator orders keys in the memtable's Snapshot, and so the row
+	// order of every SSTable flushed from it. Iterators (including
+	// range/prefix scans), level lookups and compaction compare keys as Go
+	// strings, which agrees with it only for BytewiseComparator.
+	// Its Name is persisted on first use and checked on every later open,
+	// so pointing an existing database at a different comparator fails
+	// fast rather than silently reordering it. Defaults to
+	// BytewiseComparator if left nil.
+	Comparator Comparator
 }
 
 // NewLSMTreeConfig creates a new LSM tree configuration
@@ -82,9 +110,24 @@ func NewLSMTreeConfig(memtableType string, maxMemtableSize int, dataDir string)
 		DataDir:                    dataDir,
 		EnableBackgroundCompaction: true,
 		CompactionCheckInterval:    5 * time.Second,
+		L0CompactionThreshold:      4,
+		LevelSizeMultiplier:        10,
+		BaseLevelSizeBytes:         int64(4 * maxMemtableSize),
+		L0SlowdownWritesTrigger:    8,
+		L0StopWritesTrigger:        12,
+		Comparator:                 BytewiseComparator{},
 	}
 }
 
+// writeSlowdownDelay is how long Put sleeps, once per call, while L0 is
+// between L0SlowdownWritesTrigger and L0StopWritesTrigger files.
+const writeSlowdownDelay = 1 * time.Millisecond
+
+// writeStallPollInterval is how often a blocked Put rechecks whether the
+// background flusher/compactor has made room, past L0StopWritesTrigger
+// or with both memtable slots full.
+const writeStallPollInterval = 5 * time.Millisecond
+
 // WALEntry represents a Write-Ahead Log entry
 type WALEntry struct {
 	Operation      string `json:"operation"`
@@ -121,6 +164,358 @@ func WALEntryFromString(line string) (*WALEntry, error) {
 	return &entry, err
 }
 
+// BatchOp is a single Put or Delete accumulated in a WriteBatch.
+type BatchOp struct {
+	Operation string // "PUT" or "DELETE"
+	Key       string
+	Value     string
+}
+
+// BatchReplay lets a caller iterate a decoded WriteBatch's operations in
+// order without reaching into its internal slice.
+type BatchReplay interface {
+	Len() int
+	Op(i int) BatchOp
+}
+
+// WriteBatch accumulates Put/Delete operations so they can be committed
+// to an LSMDatabase atomically, in a single WAL append and a single
+// memtable lock acquisition, rather than one WAL write per key.
+type WriteBatch struct {
+	ops []BatchOp
+}
+
+// NewWriteBatch creates an empty batch.
+func NewWriteBatch() *WriteBatch {
+	return &WriteBatch{}
+}
+
+// Put stages a key/value write.
+func (b *WriteBatch) Put(key, value string) {
+	b.ops = append(b.ops, BatchOp{Operation: "PUT", Key: key, Value: value})
+}
+
+// Delete stages a key deletion.
+func (b *WriteBatch) Delete(key string) {
+	b.ops = append(b.ops, BatchOp{Operation: "DELETE", Key: key})
+}
+
+// Len returns the number of staged operations.
+func (b *WriteBatch) Len() int {
+	return len(b.ops)
+}
+
+// Op returns the i'th staged operation, for BatchReplay callers.
+func (b *WriteBatch) Op(i int) BatchOp {
+	return b.ops[i]
+}
+
+// Size estimates the batch's serialized footprint in bytes, for
+// backpressure decisions before committing a large batch.
+func (b *WriteBatch) Size() int {
+	size := 12 // header: seqStart int64 + count uint32
+	for _, op := range b.ops {
+		size += 1 + 4 + len(op.Key) + 4 + len(op.Value)
+	}
+	return size
+}
+
+// Reset clears a batch's staged operations so it can be reused for a
+// new round of Puts/Deletes instead of allocating a fresh WriteBatch.
+func (b *WriteBatch) Reset() {
+	b.ops = b.ops[:0]
+}
+
+// encode serializes the batch as one contiguous record:
+// [seqStart:int64][count:uint32] followed by length-prefixed entries of
+// [opByte:uint8][keyLen:uint32][key][valLen:uint32][value].
+func (b *WriteBatch) encode(seqStart int64) []byte {
+	buf := make([]byte, 0, b.Size())
+
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint64(header[0:8], uint64(seqStart))
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(b.ops)))
+	buf = append(buf, header...)
+
+	for _, op := range b.ops {
+		opByte := byte(0)
+		if op.Operation == "DELETE" {
+			opByte = 1
+		}
+		buf = append(buf, opByte)
+
+		keyLen := make([]byte, 4)
+		binary.BigEndian.PutUint32(keyLen, uint32(len(op.Key)))
+		buf = append(buf, keyLen...)
+		buf = append(buf, op.Key...)
+
+		valLen := make([]byte, 4)
+		binary.BigEndian.PutUint32(valLen, uint32(len(op.Value)))
+		buf = append(buf, valLen...)
+		buf = append(buf, op.Value...)
+	}
+
+	return buf
+}
+
+// decodeWriteBatch reverses encode, returning an error for a torn
+// record (count mismatch or a length that overruns the buffer) so the
+// caller can discard the whole batch rather than partially apply it.
+func decodeWriteBatch(data []byte) (*WriteBatch, int64, error) {
+	if len(data) < 12 {
+		return nil, 0, fmt.Errorf("torn batch record: header truncated")
+	}
+
+	seqStart := int64(binary.BigEndian.Uint64(data[0:8]))
+	count := binary.BigEndian.Uint32(data[8:12])
+	offset := 12
+
+	batch := &WriteBatch{}
+	for i := uint32(0); i < count; i++ {
+		if offset+1+4 > len(data) {
+			return nil, 0, fmt.Errorf("torn batch record: truncated entry header")
+		}
+		opByte := data[offset]
+		offset++
+
+		keyLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if offset+keyLen+4 > len(data) {
+			return nil, 0, fmt.Errorf("torn batch record: truncated key")
+		}
+		key := string(data[offset : offset+keyLen])
+		offset += keyLen
+
+		valLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		offset += 4
+		if offset+valLen > len(data) {
+			return nil, 0, fmt.Errorf("torn batch record: truncated value")
+		}
+		value := string(data[offset : offset+valLen])
+		offset += valLen
+
+		operation := "PUT"
+		if opByte == 1 {
+			operation = "DELETE"
+		}
+		batch.ops = append(batch.ops, BatchOp{Operation: operation, Key: key, Value: value})
+	}
+
+	if len(batch.ops) != int(count) {
+		return nil, 0, fmt.Errorf("torn batch record: count mismatch")
+	}
+
+	return batch, seqStart, nil
+}
+
+// WAL physical record framing, modeled on LevelDB's log format: entries
+// are packed into fixed-size blocks as a sequence of
+// [crc32:uint32][length:uint16][type:uint8] headers followed by a
+// fragment, so a partial write at the tail is detected and discarded
+// instead of silently truncating a JSON line or corrupting the one
+// after it.
+const (
+	walBlockSize        = 32 * 1024
+	walRecordHeaderSize = 7
+)
+
+// walRecordType marks a physical record's place within its logical
+// record. walRecPadding (an all-zero header) fills a block's unused
+// trailer when it's too short to hold another header; walRecFull holds
+// a whole logical record; walRecFirst/Middle/Last fragment a logical
+// record that spans more than one block.
+type walRecordType byte
+
+const (
+	walRecPadding walRecordType = 0
+	walRecFull    walRecordType = 1
+	walRecFirst   walRecordType = 2
+	walRecMiddle  walRecordType = 3
+	walRecLast    walRecordType = 4
+)
+
+// Logical record payload tags. Every logical record (the reassembly of
+// one or more physical records) starts with one of these, distinguishing
+// a standalone WALEntry from an encoded WriteBatch now that both travel
+// over the same binary record stream.
+const (
+	walLogicalEntry byte = 0
+	walLogicalBatch byte = 1
+)
+
+// crc32cTable is the Castagnoli polynomial, used (rather than IEEE) for
+// the same reason leveldb picks it: a cheaper hardware-accelerated path
+// on modern CPUs and better error detection for the short records a WAL
+// actually writes.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc32Fragment checksums a physical record the same way on write and
+// read: over the type byte followed by the fragment, so a flipped type
+// byte is also caught.
+func crc32Fragment(recType walRecordType, fragment []byte) uint32 {
+	h := crc32.New(crc32cTable)
+	h.Write([]byte{byte(recType)})
+	h.Write(fragment)
+	return h.Sum32()
+}
+
+// writePhysicalRecord writes one [crc32][length][type] header plus
+// fragment to f.
+func writePhysicalRecord(f *os.File, recType walRecordType, fragment []byte) error {
+	header := make([]byte, walRecordHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:4], crc32Fragment(recType, fragment))
+	binary.LittleEndian.PutUint16(header[4:6], uint16(len(fragment)))
+	header[6] = byte(recType)
+
+	if _, err := f.Write(header); err != nil {
+		return err
+	}
+	if len(fragment) > 0 {
+		if _, err := f.Write(fragment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendLogicalRecord packs payload into one or more physical records
+// and writes them to f, splitting at walBlockSize block boundaries (as
+// FIRST/MIDDLE/LAST) when it doesn't fit in the current block, and
+// zero-padding a block's trailer when it's too short to hold even one
+// more header.
+func appendLogicalRecord(f *os.File, payload []byte) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	pos := info.Size() % walBlockSize
+
+	first := true
+	for first || len(payload) > 0 {
+		leftover := walBlockSize - pos
+		if leftover < walRecordHeaderSize+1 {
+			if leftover > 0 {
+				if _, err := f.Write(make([]byte, leftover)); err != nil {
+					return err
+				}
+			}
+			pos = 0
+			leftover = walBlockSize
+		}
+
+		avail := leftover - walRecordHeaderSize
+		n := int64(len(payload))
+		if n > avail {
+			n = avail
+		}
+		fragment := payload[:n]
+		payload = payload[n:]
+
+		var recType walRecordType
+		switch {
+		case first && len(payload) == 0:
+			recType = walRecFull
+		case first:
+			recType = walRecFirst
+		case len(payload) == 0:
+			recType = walRecLast
+		default:
+			recType = walRecMiddle
+		}
+
+		if err := writePhysicalRecord(f, recType, fragment); err != nil {
+			return err
+		}
+		pos += walRecordHeaderSize + n
+		first = false
+	}
+
+	return nil
+}
+
+// readLogicalRecords replays every physical record in path's WAL blocks
+// and reassembles them into logical records (a FULL record on its own,
+// or a FIRST..LAST run concatenated). It stops at the first fragment
+// whose CRC fails or whose declared length overruns the data actually on
+// disk, logging the truncated tail rather than returning an error, so a
+// torn last write can't block recovery of everything before it; skipped
+// reports how many trailing bytes were discarded that way, for
+// db.Recover()'s diagnostic.
+func readLogicalRecords(path string) (records [][]byte, skipped int64, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+
+	var logical []byte
+	inProgress := false
+
+	for blockStart := 0; blockStart < len(data); blockStart += walBlockSize {
+		blockEnd := blockStart + walBlockSize
+		if blockEnd > len(data) {
+			blockEnd = len(data)
+		}
+		block := data[blockStart:blockEnd]
+
+		for bpos := 0; bpos < len(block); {
+			if len(block)-bpos < walRecordHeaderSize {
+				break // trailer padding, or a torn header at EOF: next block
+			}
+
+			header := block[bpos : bpos+walRecordHeaderSize]
+			crcWant := binary.LittleEndian.Uint32(header[0:4])
+			length := int(binary.LittleEndian.Uint16(header[4:6]))
+			recType := walRecordType(header[6])
+
+			if recType == walRecPadding {
+				break // zero-filled trailer: skip the rest of this block
+			}
+
+			fragStart := bpos + walRecordHeaderSize
+			fragEnd := fragStart + length
+			if fragEnd > len(block) {
+				log.Printf("WAL: record at offset %d overruns EOF, stopping replay of truncated tail", blockStart+bpos)
+				return records, int64(len(data) - (blockStart + bpos)), nil
+			}
+			fragment := block[fragStart:fragEnd]
+
+			if crc32Fragment(recType, fragment) != crcWant {
+				log.Printf("WAL: CRC mismatch at offset %d, stopping replay of truncated tail", blockStart+bpos)
+				return records, int64(len(data) - (blockStart + bpos)), nil
+			}
+
+			switch recType {
+			case walRecFull:
+				records = append(records, append([]byte{}, fragment...))
+				logical, inProgress = nil, false
+			case walRecFirst:
+				logical = append([]byte{}, fragment...)
+				inProgress = true
+			case walRecMiddle:
+				if inProgress {
+					logical = append(logical, fragment...)
+				}
+			case walRecLast:
+				if inProgress {
+					logical = append(logical, fragment...)
+					records = append(records, logical)
+					logical, inProgress = nil, false
+				}
+			default:
+				log.Printf("WAL: unknown record type %d at offset %d, stopping replay", recType, blockStart+bpos)
+				return records, int64(len(data) - (blockStart + bpos)), nil
+			}
+
+			bpos = fragEnd
+		}
+	}
+
+	return records, 0, nil
+}
+
 // WALManager manages Write-Ahead Logging
 type WALManager struct {
 	WALFile         string
@@ -139,7 +534,8 @@ func NewWALManager(dataDir, dbName string, enabled bool) *WALManager {
 	}
 }
 
-// AppendEntry appends a WAL entry to the log file
+// AppendEntry appends a WAL entry to the log file, as one or more
+// length-prefixed, CRC-checksummed physical records.
 func (wm *WALManager) AppendEntry(entry *WALEntry) error {
 	if !wm.IsEnabled {
 		return nil
@@ -167,14 +563,50 @@ func (wm *WALManager) AppendEntry(entry *WALEntry) error {
 		return err
 	}
 
-	if _, err := file.WriteString(entryStr + "\n"); err != nil {
-		return err
+	payload := append([]byte{walLogicalEntry}, entryStr...)
+	return appendLogicalRecord(file, payload)
+}
+
+// AppendBatch appends an entire WriteBatch as a single logical WAL
+// record, tagged walLogicalBatch, so the whole batch commits (or is
+// discarded on replay) atomically instead of one WAL write per staged
+// operation. It returns seqStart, the sequence number assigned to the
+// batch's first op (subsequent ops get seqStart+1, seqStart+2, ...), so
+// the caller can apply each op at its own sequence number.
+func (wm *WALManager) AppendBatch(batch *WriteBatch) (int64, error) {
+	if !wm.IsEnabled {
+		return 0, nil
 	}
 
-	return nil
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	seqStart := wm.CurrentSequence
+	wm.CurrentSequence += int64(batch.Len())
+
+	if err := os.MkdirAll(filepath.Dir(wm.WALFile), 0755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(wm.WALFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	payload := append([]byte{walLogicalBatch}, batch.encode(seqStart)...)
+	if err := appendLogicalRecord(file, payload); err != nil {
+		return 0, err
+	}
+
+	// fsync once for the whole batch, rather than per operation.
+	return seqStart, file.Sync()
 }
 
-// GetEntries reads all WAL entries from the log file
+// GetEntries reads every standalone WALEntry record from the log file,
+// skipping WriteBatch records (callers that need those should use
+// Replay). CRC failures or a torn tail record stop the read at that
+// point rather than erroring.
 func (wm *WALManager) GetEntries() ([]*WALEntry, error) {
 	var entries []*WALEntry
 
@@ -182,23 +614,16 @@ func (wm *WALManager) GetEntries() ([]*WALEntry, error) {
 		return entries, nil
 	}
 
-	file, err := os.Open(wm.WALFile)
+	records, _, err := readLogicalRecords(wm.WALFile)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return entries, nil
-		}
 		return nil, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+	for _, rec := range records {
+		if len(rec) == 0 || rec[0] != walLogicalEntry {
 			continue
 		}
-
-		entry, err := WALEntryFromString(line)
+		entry, err := WALEntryFromString(string(rec[1:]))
 		if err != nil {
 			log.Printf("Error parsing WAL entry: %v", err)
 			continue
@@ -206,7 +631,79 @@ func (wm *WALManager) GetEntries() ([]*WALEntry, error) {
 		entries = append(entries, entry)
 	}
 
-	return entries, scanner.Err()
+	return entries, nil
+}
+
+// ReplayReport summarizes one WAL file's replay: how many logical
+// records (entries plus individual batch ops) were applied, how many
+// trailing bytes were discarded because a record was torn or failed its
+// checksum, and the highest sequence number recovered from the file.
+type ReplayReport struct {
+	Applied      int
+	BytesSkipped int64
+	HighestSeq   int64
+}
+
+// Replay reassembles every logical WAL record in order, invoking
+// applyEntry for a standalone WALEntry and applyBatch (with the batch's
+// decoded seqStart) for a decoded WriteBatch. A torn batch (truncated
+// header, short read, or count mismatch) is logged and discarded
+// wholesale rather than partially applied, so a crash mid-write can
+// never leave a batch half-committed.
+func (wm *WALManager) Replay(applyEntry func(*WALEntry), applyBatch func(*WriteBatch, int64)) (*ReplayReport, error) {
+	return wm.ReplayFile(wm.WALFile, applyEntry, applyBatch)
+}
+
+// ReplayFile is like Replay but reads an arbitrary WAL file rather than
+// wm.WALFile, so a frozen journal left behind by a crash mid-flush can be
+// recovered the same way the active WAL is.
+func (wm *WALManager) ReplayFile(path string, applyEntry func(*WALEntry), applyBatch func(*WriteBatch, int64)) (*ReplayReport, error) {
+	report := &ReplayReport{}
+
+	if !wm.IsEnabled {
+		return report, nil
+	}
+
+	records, skipped, err := readLogicalRecords(path)
+	report.BytesSkipped = skipped
+	if err != nil {
+		return report, err
+	}
+
+	for _, rec := range records {
+		if len(rec) == 0 {
+			continue
+		}
+
+		switch rec[0] {
+		case walLogicalBatch:
+			batch, seqStart, err := decodeWriteBatch(rec[1:])
+			if err != nil {
+				log.Printf("Discarding torn WAL batch: %v", err)
+				continue
+			}
+			applyBatch(batch, seqStart)
+			report.Applied += batch.Len()
+			if highest := seqStart + int64(batch.Len()) - 1; highest > report.HighestSeq {
+				report.HighestSeq = highest
+			}
+		case walLogicalEntry:
+			entry, err := WALEntryFromString(string(rec[1:]))
+			if err != nil {
+				log.Printf("Error parsing WAL entry: %v", err)
+				continue
+			}
+			applyEntry(entry)
+			report.Applied++
+			if entry.SequenceNumber > report.HighestSeq {
+				report.HighestSeq = entry.SequenceNumber
+			}
+		default:
+			log.Printf("Skipping WAL record with unknown logical type %d", rec[0])
+		}
+	}
+
+	return report, nil
 }
 
 // Clear clears the WAL file after successful checkpoint
@@ -221,6 +718,44 @@ func (wm *WALManager) Clear() error {
 	return os.Remove(wm.WALFile)
 }
 
+// Rotate renames the active WAL file out of the way (to <path>.frozen)
+// so a frozen memtable's already-durable writes stay recoverable under
+// their own file while a fresh WAL starts accumulating entries for the
+// memtable that replaces it. It returns "" if there's nothing to rotate:
+// WAL is disabled, or no file has been written yet.
+func (wm *WALManager) Rotate() (string, error) {
+	if !wm.IsEnabled {
+		return "", nil
+	}
+
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+
+	if _, err := os.Stat(wm.WALFile); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	frozenPath := wm.WALFile + ".frozen"
+	if err := os.Rename(wm.WALFile, frozenPath); err != nil {
+		return "", err
+	}
+	return frozenPath, nil
+}
+
+// ClearFile removes a specific WAL file, such as a frozen journal whose
+// memtable has now been durably flushed to an SSTable. A missing file is
+// not an error: the flush may already have removed it, or recovery may
+// be clearing one it only replayed speculatively.
+func (wm *WALManager) ClearFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // DatabaseMetrics tracks database performance metrics
 type DatabaseMetrics struct {
 	TotalOperations  int64
@@ -284,50 +819,108 @@ func (dm *DatabaseMetrics) GetStats() map[string]int64 {
 	}
 }
 
-// Memtable represents an in-memory table
+// versionedValue is one write's state for a key at a given sequence
+// number. Memtable keeps every version rather than overwriting in place,
+// so a Snapshot taken before a later write still has something to read.
+type versionedValue struct {
+	Seq     int64
+	Value   string
+	Deleted bool
+}
+
+// Memtable represents an in-memory table. Each key maps to its versions,
+// appended in increasing Seq order, so both "give me the latest value"
+// and "give me the value as of sequence N" reads are answerable from the
+// same storage.
 type Memtable struct {
-	data    map[string]string
+	data    map[string][]versionedValue
 	size    int
 	maxSize int
+	maxSeq  int64
 	mutex   sync.RWMutex
+	cmp     Comparator
 }
 
-// NewMemtable creates a new memtable
-func NewMemtable(maxSize int) *Memtable {
+// NewMemtable creates a new memtable ordered by cmp. A nil cmp defaults
+// to BytewiseComparator, the ordering every caller relied on before
+// Comparator existed.
+func NewMemtable(maxSize int, cmp Comparator) *Memtable {
+	if cmp == nil {
+		cmp = BytewiseComparator{}
+	}
 	return &Memtable{
-		data:    make(map[string]string),
+		data:    make(map[string][]versionedValue),
 		size:    0,
 		maxSize: maxSize,
+		cmp:     cmp,
 	}
 }
 
-// Put inserts or updates a key-value pair
-func (mt *Memtable) Put(key, value string) {
+// Put appends a new version of key at seq. Earlier versions are kept,
+// not overwritten, so a snapshot taken before seq still sees them.
+func (mt *Memtable) Put(key, value string, seq int64) {
 	mt.mutex.Lock()
 	defer mt.mutex.Unlock()
 
-	oldSize := len(mt.data[key])
-	mt.data[key] = value
-	mt.size += len(value) - oldSize
+	mt.data[key] = append(mt.data[key], versionedValue{Seq: seq, Value: value})
+	mt.size += len(key) + len(value)
+	if seq > mt.maxSeq {
+		mt.maxSeq = seq
+	}
 }
 
-// Get retrieves a value for a key
+// Get retrieves the latest value for a key. A tombstoned key is reported
+// as not-present, matching the "not found" contract callers already
+// expect.
 func (mt *Memtable) Get(key string) (string, bool) {
+	value, deleted, found := mt.Lookup(key)
+	if deleted {
+		return "", false
+	}
+	return value, found
+}
+
+// Lookup is like Get but also reports whether the latest version is a
+// tombstone, so callers that need to stop a newest-wins search at a
+// delete (rather than falling through to an older layer) can tell the
+// two cases apart.
+func (mt *Memtable) Lookup(key string) (value string, deleted bool, found bool) {
 	mt.mutex.RLock()
 	defer mt.mutex.RUnlock()
 
-	value, exists := mt.data[key]
-	return value, exists
+	versions := mt.data[key]
+	if len(versions) == 0 {
+		return "", false, false
+	}
+	latest := versions[len(versions)-1]
+	return latest.Value, latest.Deleted, true
 }
 
-// Delete removes a key
-func (mt *Memtable) Delete(key string) {
+// LookupAt is like Lookup but restricted to versions visible to a
+// snapshot at maxSeq: the newest version with Seq <= maxSeq, ignoring
+// anything written after the snapshot was taken.
+func (mt *Memtable) LookupAt(key string, maxSeq int64) (value string, deleted bool, found bool) {
+	mt.mutex.RLock()
+	defer mt.mutex.RUnlock()
+
+	versions := mt.data[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Seq <= maxSeq {
+			return versions[i].Value, versions[i].Deleted, true
+		}
+	}
+	return "", false, false
+}
+
+// Delete appends a tombstone version of key at seq.
+func (mt *Memtable) Delete(key string, seq int64) {
 	mt.mutex.Lock()
 	defer mt.mutex.Unlock()
 
-	if _, exists := mt.data[key]; exists {
-		delete(mt.data, key)
-		mt.size -= len(key) + len(mt.data[key])
+	mt.data[key] = append(mt.data[key], versionedValue{Seq: seq, Deleted: true})
+	mt.size += len(key)
+	if seq > mt.maxSeq {
+		mt.maxSeq = seq
 	}
 }
 
@@ -345,18 +938,63 @@ func (mt *Memtable) IsFull() bool {
 	return mt.size >= mt.maxSize
 }
 
-// GetAllData returns all data (for flushing)
+// MaxSeq returns the highest sequence number applied to this memtable,
+// the basis for a snapshot taken while this is the active memtable.
+func (mt *Memtable) MaxSeq() int64 {
+	mt.mutex.RLock()
+	defer mt.mutex.RUnlock()
+	return mt.maxSeq
+}
+
+// GetAllData returns all live, latest-version data (for flushing)
 func (mt *Memtable) GetAllData() map[string]string {
 	mt.mutex.RLock()
 	defer mt.mutex.RUnlock()
 
 	result := make(map[string]string)
-	for k, v := range mt.data {
-		result[k] = v
+	for k, versions := range mt.data {
+		latest := versions[len(versions)-1]
+		if !latest.Deleted {
+			result[k] = latest.Value
+		}
 	}
 	return result
 }
 
+// MemtableEntry is one version of one key in a point-in-time memtable
+// snapshot.
+type MemtableEntry struct {
+	Key     string
+	Value   string
+	Deleted bool
+	Seq     int64
+}
+
+// Snapshot returns every version of every key currently tracked by the
+// memtable, live or tombstoned, sorted by key and then by sequence
+// number ascending. It is the basis for the memtable's sub-iterator in a
+// k-way merge, and for flushing a memtable to an SSTable without losing
+// either its tombstones or the per-version history a live Snapshot might
+// still need.
+func (mt *Memtable) Snapshot() []MemtableEntry {
+	mt.mutex.RLock()
+	defer mt.mutex.RUnlock()
+
+	var entries []MemtableEntry
+	for k, versions := range mt.data {
+		for _, v := range versions {
+			entries = append(entries, MemtableEntry{Key: k, Value: v.Value, Deleted: v.Deleted, Seq: v.Seq})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if c := mt.cmp.Compare([]byte(entries[i].Key), []byte(entries[j].Key)); c != 0 {
+			return c < 0
+		}
+		return entries[i].Seq < entries[j].Seq
+	})
+	return entries
+}
+
 // SSTable represents a Sorted String Table using Arrow/Parquet
 type SSTable struct {
 	Filename string
@@ -370,6 +1008,13 @@ type SSTableMetadata struct {
 	NumEntries int64
 	FileSize   int64
 	CreatedAt  time.Time
+	// Level is this file's position in the leveled layout: L0 files may
+	// overlap in key range, Li (i>=1) files are non-overlapping.
+	Level int
+	// bloom is loaded once per SSTable (at WriteSSTable time, or from the
+	// file's Parquet key-value metadata when reconstructed from the
+	// MANIFEST) so point reads can rule out a miss without touching disk.
+	bloom *bloomFilter
 }
 
 // NewSSTable creates a new SSTable
@@ -382,28 +1027,31 @@ func NewSSTable(filename string) *SSTable {
 	}
 }
 
-// WriteSSTable writes memtable data to SSTable using Arrow/Parquet
-func WriteSSTable(data map[string]string, filename string) (*SSTable, error) {
+// SSTableEntry is one row written to an SSTable: a key, its value,
+// whether the row is a tombstone recording a deletion rather than a put,
+// and the sequence number the write happened at, so a snapshot reading
+// the file can tell which versions were visible as of its Seq. It's an
+// alias for MemtableEntry rather than a separate identical struct, since
+// every SSTable is written directly from a memtable (or compaction)
+// snapshot - a distinct type here would just force a field-by-field copy
+// at every call site for no benefit.
+type SSTableEntry = MemtableEntry
+
+// WriteSSTable writes a sorted batch of entries to an SSTable using
+// Arrow/Parquet, tagged with the level it belongs to. entries must
+// already be sorted by key, and within a key by Seq ascending
+// (Memtable.Snapshot and the compaction merge both guarantee this),
+// since readers stream row groups back in file order to merge them with
+// other sorted sources.
+func WriteSSTable(entries []SSTableEntry, filename string, level int) (*SSTable, error) {
 	alloc := memory.NewGoAllocator()
 
-	// Create sorted keys
-	var keys []string
-	for k := range data {
-		keys = append(keys, k)
-	}
-	// Simple sort (in real implementation, use proper sorting)
-	for i := 0; i < len(keys)-1; i++ {
-		for j := i + 1; j < len(keys); j++ {
-			if keys[i] > keys[j] {
-				keys[i], keys[j] = keys[j], keys[i]
-			}
-		}
-	}
-
 	// Create Arrow schema
 	schema := arrow.NewSchema([]arrow.Field{
 		{Name: "key", Type: arrow.BinaryTypes.String},
 		{Name: "value", Type: arrow.BinaryTypes.String},
+		{Name: "deleted", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "seq", Type: arrow.PrimitiveTypes.Int64},
 	}, nil)
 
 	// Build record
@@ -412,15 +1060,27 @@ func WriteSSTable(data map[string]string, filename string) (*SSTable, error) {
 
 	keyBuilder := builder.Field(0).(*array.StringBuilder)
 	valueBuilder := builder.Field(1).(*array.StringBuilder)
+	deletedBuilder := builder.Field(2).(*array.BooleanBuilder)
+	seqBuilder := builder.Field(3).(*array.Int64Builder)
 
-	for _, key := range keys {
-		keyBuilder.Append(key)
-		valueBuilder.Append(data[key])
+	for _, e := range entries {
+		keyBuilder.Append(e.Key)
+		valueBuilder.Append(e.Value)
+		deletedBuilder.Append(e.Deleted)
+		seqBuilder.Append(e.Seq)
 	}
 
 	record := builder.NewRecord()
 	defer record.Release()
 
+	// Build a Bloom filter over every key written, tombstones included, so
+	// point reads against this
+	// file can rule out a miss from the key-value metadata alone.
+	bloom := newBloomFilter(len(entries))
+	for _, e := range entries {
+		bloom.Add(e.Key)
+	}
+
 	// Write to Parquet
 	file, err := os.Create(filename)
 	if err != nil {
@@ -428,7 +1088,8 @@ func WriteSSTable(data map[string]string, filename string) (*SSTable, error) {
 	}
 	defer file.Close()
 
-	writer, err := pqarrow.NewFileWriter(record.Schema(), file, parquet.NewWriterProperties(), pqarrow.NewArrowWriterProperties())
+	props := parquet.NewWriterProperties()
+	writer, err := pqarrow.NewFileWriter(record.Schema(), file, props, pqarrow.NewArrowWriterProperties())
 	if err != nil {
 		return nil, err
 	}
@@ -437,13 +1098,18 @@ func WriteSSTable(data map[string]string, filename string) (*SSTable, error) {
 	if err := writer.Write(record); err != nil {
 		return nil, err
 	}
+	if err := attachBloomMetadata(writer, bloom); err != nil {
+		return nil, err
+	}
 
 	// Create SSTable metadata
 	sstable := NewSSTable(filename)
-	sstable.Metadata.NumEntries = int64(len(data))
-	if len(keys) > 0 {
-		sstable.Metadata.MinKey = keys[0]
-		sstable.Metadata.MaxKey = keys[len(keys)-1]
+	sstable.Metadata.Level = level
+	sstable.Metadata.bloom = bloom
+	sstable.Metadata.NumEntries = int64(len(entries))
+	if len(entries) > 0 {
+		sstable.Metadata.MinKey = entries[0].Key
+		sstable.Metadata.MaxKey = entries[len(entries)-1].Key
 	}
 
 	// Get file size
@@ -454,7 +1120,10 @@ func WriteSSTable(data map[string]string, filename string) (*SSTable, error) {
 	return sstable, nil
 }
 
-// ReadSSTable reads SSTable data
+// ReadSSTable reads SSTable data, folding multiple versions of a key
+// (rows are ordered ascending by Seq) down to its latest one and
+// dropping the key when that latest row is a tombstone, so the
+// result is just the live key-value pairs this SSTable contributes.
 func (sst *SSTable) ReadSSTable() (map[string]string, error) {
 	data := make(map[string]string)
 
@@ -483,6 +1152,7 @@ func (sst *SSTable) ReadSSTable() (map[string]string, error) {
 
 	keyCol := table.Column(0).Data()
 	valueCol := table.Column(1).Data()
+	deletedCol := table.Column(2).Data()
 
 	if keyCol.Len() != valueCol.Len() {
 		return nil, fmt.Errorf("key and value columns have different lengths")
@@ -491,12 +1161,19 @@ func (sst *SSTable) ReadSSTable() (map[string]string, error) {
 	for i := 0; i < keyCol.Len(); i++ {
 		keyChunk := keyCol.Chunk(0)
 		valueChunk := valueCol.Chunk(0)
+		deletedChunk := deletedCol.Chunk(0)
 
 		if keyChunk.Len() > i && valueChunk.Len() > i {
 			keyArr := keyChunk.(*array.String)
 			valueArr := valueChunk.(*array.String)
+			deletedArr := deletedChunk.(*array.Boolean)
 
 			key := keyArr.Value(i)
+			if deletedArr.Value(i) {
+				delete(data, key)
+				continue
+			}
+
 			value := valueArr.Value(i)
 			data[key] = value
 		}
@@ -507,64 +1184,204 @@ func (sst *SSTable) ReadSSTable() (map[string]string, error) {
 
 // LSMTree represents the Log-Structured Merge Tree
 type LSMTree struct {
-	Config             *LSMTreeConfig
-	Memtable           *Memtable
-	ImmutableMemtables []*Memtable
-	SSTables           []*SSTable
-	mutex              sync.RWMutex
+	Config    *LSMTreeConfig
+	Memtable  *Memtable
+	wal       *WALManager
+	frozenMem *Memtable
+	// frozenJournalFile is the rotated WAL file backing frozenMem. It's
+	// empty whenever frozenMem is nil, and also while frozenMem is set if
+	// the WAL is disabled or had no file to rotate. It is only removed
+	// once frozenMem has been durably flushed to an SSTable, so a crash
+	// mid-flush can still recover frozenMem's writes on restart.
+	frozenJournalFile string
+	// Levels holds the leveled SSTable layout: Levels[0] is L0, whose
+	// files may overlap in key range; Levels[i] for i>=1 is non-overlapping
+	// and sorted by MinKey.
+	Levels         [][]*SSTable
+	mutex          sync.RWMutex
+	stopCompaction chan struct{}
+
+	// mcompCmdC wakes memtableCompactionLoop the moment a memtable is
+	// frozen, rather than leaving it to poll; tcompCmdC does the same for
+	// tableCompactionLoop once a flush lands a new L0 file. Both are
+	// buffered 1 so a nudge while the loop is already busy is a no-op
+	// instead of blocking the writer that sent it.
+	mcompCmdC chan struct{}
+	tcompCmdC chan struct{}
+	// compErrC carries a background flush or compaction failure to the
+	// next Put/Get (see checkBackgroundError), instead of the error only
+	// ever reaching a log line no caller can react to.
+	compErrC chan error
+
+	// cWriteDelay and cWriteDelayN (both atomic) track how much
+	// write-delay backpressure throttleWrite has applied, in total
+	// nanoseconds slept and number of Puts/Deletes delayed; inWritePaused
+	// (atomic bool) reports whether a write is currently hard-blocked past
+	// L0StopWritesTrigger. All three are surfaced via GetStats so the
+	// recovery/perf demos can report compaction backpressure.
+	cWriteDelay   int64
+	cWriteDelayN  int64
+	inWritePaused int32
+
+	// flushingFrozen (atomic bool) guards flushFrozenMem against running
+	// twice concurrently for the same frozenMem: memtableCompactionLoop's
+	// own nudge and Close's flushActiveMemtableSync can both decide
+	// frozenMem needs flushing around the same time.
+	flushingFrozen int32
+
+	// snapMu guards snapsList, the sequence numbers of every open
+	// Snapshot, kept as a goleveldb-style linked list rather than a
+	// sorted slice so registering and releasing a snapshot are O(1).
+	// Snapshots are always registered in non-decreasing seq order (a
+	// later NewSnapshot only ever sees a larger-or-equal max sequence),
+	// so appending to the back keeps the list ascending; its front is
+	// the GC watermark compaction must not collapse a version past,
+	// since some live snapshot might still need it.
+	snapMu     sync.Mutex
+	snapsList  *list.List
+	aliveSnaps int
+
+	// comparator orders the memtable's Snapshot, and so the rows of every
+	// SSTable flushed from it. Iterator bounds and merge order, level
+	// lookups and compaction compare keys as Go strings instead. Its Name is checked against comparatorPath on open (see
+	// NewLSMTree) so a mismatched comparator fails fast instead of
+	// silently reordering existing data.
+	comparator Comparator
 }
 
-// NewLSMTree creates a new LSM tree
-func NewLSMTree(config *LSMTreeConfig) *LSMTree {
-	lsm := &LSMTree{
-		Config:   config,
-		Memtable: NewMemtable(config.MaxMemtableSize),
-		SSTables: make([]*SSTable, 0),
+// NewLSMTree creates a new LSM tree. wal is the WALManager whose journal
+// gets rotated into frozenJournalFile each time a memtable is frozen; it
+// must already be constructed (and have replayed anything pre-existing)
+// by the time Put starts accepting writes. It fails if config.Comparator
+// doesn't match the comparator an existing database at config.DataDir
+// was already built with.
+func NewLSMTree(config *LSMTreeConfig, wal *WALManager) (*LSMTree, error) {
+	cmp := config.Comparator
+	if cmp == nil {
+		cmp = BytewiseComparator{}
+	}
+	if err := checkComparator(config.DataDir, cmp); err != nil {
+		return nil, err
 	}
 
-	// Load existing SSTables from disk
-	lsm.loadExistingSSTables()
+	lsm := &LSMTree{
+		Config:     config,
+		Memtable:   NewMemtable(config.MaxMemtableSize, cmp),
+		wal:        wal,
+		Levels:     make([][]*SSTable, 1), // always at least L0
+		snapsList:  list.New(),
+		mcompCmdC:  make(chan struct{}, 1),
+		tcompCmdC:  make(chan struct{}, 1),
+		compErrC:   make(chan error, 1),
+		comparator: cmp,
+	}
+
+	// Reconstruct the level layout from the MANIFEST instead of guessing
+	// it from filenames.
+	lsm.loadManifest()
+
+	// memtableCompactionLoop and tableCompactionLoop always run: a frozen
+	// memtable must get flushed and its level compacted regardless of
+	// EnableBackgroundCompaction, which only controls whether
+	// tableCompactionLoop *also* rechecks every level on a timer instead
+	// of purely in response to a flush.
+	lsm.stopCompaction = make(chan struct{})
+	go lsm.tableCompactionLoop()
+	go lsm.memtableCompactionLoop()
+
+	return lsm, nil
+}
 
-	return lsm
+// Close stops the background compactor and flusher, if they were started.
+func (lsm *LSMTree) Close() {
+	if lsm.stopCompaction != nil {
+		close(lsm.stopCompaction)
+	}
 }
 
-// Put inserts or updates a key-value pair
-func (lsm *LSMTree) Put(key, value string) {
+// Put inserts or updates a key-value pair at seq, the WAL sequence
+// number the write was assigned. It first applies throttleWrite's
+// write-delay/write-stall backpressure, then freezes the active memtable
+// (waking the background flusher) if it's now full, and finally surfaces
+// any background flush/compaction error pending on compErrC.
+func (lsm *LSMTree) Put(key, value string, seq int64) error {
+	lsm.throttleWrite()
+
 	lsm.mutex.Lock()
-	defer lsm.mutex.Unlock()
+	lsm.Memtable.Put(key, value, seq)
+	if lsm.Memtable.IsFull() && lsm.frozenMem == nil {
+		lsm.rotateMemtableLocked()
+	}
+	lsm.mutex.Unlock()
 
-	lsm.Memtable.Put(key, value)
+	return lsm.checkBackgroundError()
+}
 
-	// Check if memtable needs to be flushed
-	if lsm.Memtable.IsFull() {
-		lsm.flushMemtable()
-	}
+// Get retrieves the latest value for a key, searching layers newest-first
+// so a tombstone in a more recent layer stops the search before an older
+// layer's stale value can be returned.
+func (lsm *LSMTree) Get(key string) (string, error) {
+	lsm.mutex.RLock()
+	value := lsm.getAtLocked(key, math.MaxInt64)
+	lsm.mutex.RUnlock()
+	return value, lsm.checkBackgroundError()
 }
 
-// Get retrieves a value for a key
-func (lsm *LSMTree) Get(key string) string {
+// GetAt retrieves the value for a key as seen by a snapshot at maxSeq,
+// ignoring any version written after that sequence number.
+func (lsm *LSMTree) GetAt(key string, maxSeq int64) (string, error) {
 	lsm.mutex.RLock()
-	defer lsm.mutex.RUnlock()
+	value := lsm.getAtLocked(key, maxSeq)
+	lsm.mutex.RUnlock()
+	return value, lsm.checkBackgroundError()
+}
 
+// getAtLocked is Get/GetAt's shared search, bounded to versions with
+// Seq <= maxSeq. Callers must already hold lsm.mutex for reading.
+func (lsm *LSMTree) getAtLocked(key string, maxSeq int64) string {
 	// Check memtable first
-	if value, exists := lsm.Memtable.Get(key); exists {
+	if value, deleted, found := lsm.Memtable.LookupAt(key, maxSeq); found {
+		if deleted {
+			return ""
+		}
 		return value
 	}
 
-	// Check immutable memtables
-	for _, imm := range lsm.ImmutableMemtables {
-		if value, exists := imm.Get(key); exists {
+	// Check the frozen memtable, if one is awaiting flush.
+	if lsm.frozenMem != nil {
+		if value, deleted, found := lsm.frozenMem.LookupAt(key, maxSeq); found {
+			if deleted {
+				return ""
+			}
 			return value
 		}
 	}
 
-	// Check SSTables (simplified - should use binary search)
-	for _, sst := range lsm.SSTables {
-		data, err := sst.ReadSSTable()
-		if err != nil {
+	// L0 files may overlap, so every file must be checked, newest first.
+	if len(lsm.Levels) > 0 {
+		l0 := lsm.Levels[0]
+		for i := len(l0) - 1; i >= 0; i-- {
+			if value, deleted, found := lookupInSSTableAt(l0[i], key, maxSeq); found {
+				if deleted {
+					return ""
+				}
+				return value
+			}
+		}
+	}
+
+	// Li (i>=1) files are non-overlapping and sorted by MinKey, so at
+	// most one file per level can contain the key.
+	for level := 1; level < len(lsm.Levels); level++ {
+		files := lsm.Levels[level]
+		idx := sort.Search(len(files), func(i int) bool { return files[i].Metadata.MaxKey >= key })
+		if idx >= len(files) || files[idx].Metadata.MinKey > key {
 			continue
 		}
-		if value, exists := data[key]; exists {
+		if value, deleted, found := lookupInSSTableAt(files[idx], key, maxSeq); found {
+			if deleted {
+				return ""
+			}
 			return value
 		}
 	}
@@ -572,54 +1389,235 @@ func (lsm *LSMTree) Get(key string) string {
 	return "" // Not found
 }
 
-// Delete removes a key
-func (lsm *LSMTree) Delete(key string) {
+// Delete removes a key at seq, the WAL sequence number the write was
+// assigned, applying the same write-delay/write-stall backpressure as
+// Put.
+func (lsm *LSMTree) Delete(key string, seq int64) error {
+	lsm.throttleWrite()
+
 	lsm.mutex.Lock()
-	defer lsm.mutex.Unlock()
+	lsm.Memtable.Delete(key, seq)
+	if lsm.Memtable.IsFull() && lsm.frozenMem == nil {
+		lsm.rotateMemtableLocked()
+	}
+	lsm.mutex.Unlock()
 
-	lsm.Memtable.Delete(key)
+	return lsm.checkBackgroundError()
 }
 
-// flushMemtable flushes the current memtable to SSTable
-func (lsm *LSMTree) flushMemtable() {
-	// Move current memtable to immutable
-	lsm.ImmutableMemtables = append(lsm.ImmutableMemtables, lsm.Memtable)
+// registerSnapshot records seq as a live snapshot and returns the
+// snapsList element it was stored in, so releaseSnapshot can later
+// remove it in O(1) instead of a linear scan.
+func (lsm *LSMTree) registerSnapshot(seq int64) *list.Element {
+	lsm.snapMu.Lock()
+	defer lsm.snapMu.Unlock()
 
-	// Create new memtable
-	lsm.Memtable = NewMemtable(lsm.Config.MaxMemtableSize)
+	lsm.aliveSnaps++
+	return lsm.snapsList.PushBack(seq)
+}
 
-	// Flush immutable memtable to SSTable (simplified - only flush the first one)
-	if len(lsm.ImmutableMemtables) > 0 {
-		imm := lsm.ImmutableMemtables[0]
-		lsm.ImmutableMemtables = lsm.ImmutableMemtables[1:]
+// releaseSnapshot removes a previously registered snapshot's element
+// from snapsList.
+func (lsm *LSMTree) releaseSnapshot(elem *list.Element) {
+	lsm.snapMu.Lock()
+	defer lsm.snapMu.Unlock()
 
-		data := imm.GetAllData()
-		if len(data) > 0 {
-			filename := filepath.Join(lsm.Config.DataDir, fmt.Sprintf("sstable_%d.parquet", time.Now().Unix()))
-			sstable, err := WriteSSTable(data, filename)
-			if err != nil {
-				log.Printf("Error writing SSTable: %v", err)
-				return
-			}
-			lsm.SSTables = append(lsm.SSTables, sstable)
+	lsm.snapsList.Remove(elem)
+	lsm.aliveSnaps--
+}
+
+// gcWatermark returns the smallest live snapshot sequence (snapsList's
+// front), or math.MaxInt64 when aliveSnaps is zero, so compaction knows
+// the oldest point a live reader might still need and never collapses a
+// version past it.
+func (lsm *LSMTree) gcWatermark() int64 {
+	lsm.snapMu.Lock()
+	defer lsm.snapMu.Unlock()
+
+	if lsm.aliveSnaps == 0 {
+		return math.MaxInt64
+	}
+	return lsm.snapsList.Front().Value.(int64)
+}
+
+// throttleWrite applies goleveldb-style write backpressure before a
+// Put/Delete touches the memtable: once L0 has accumulated
+// L0SlowdownWritesTrigger files it sleeps writeSlowdownDelay (recording
+// cWriteDelay/cWriteDelayN) so the compactor gets a chance to catch up,
+// and past L0StopWritesTrigger — or with the one frozen-memtable slot
+// already occupied and the active memtable also full — it blocks
+// outright (recording inWritePaused) until the background
+// flusher/compactor makes room.
+func (lsm *LSMTree) throttleWrite() {
+	for {
+		lsm.mutex.RLock()
+		l0Files := len(lsm.Levels[0])
+		noRoomToFreeze := lsm.frozenMem != nil && lsm.Memtable.IsFull()
+		lsm.mutex.RUnlock()
+
+		switch {
+		case l0Files >= lsm.Config.L0StopWritesTrigger || noRoomToFreeze:
+			atomic.StoreInt32(&lsm.inWritePaused, 1)
+			time.Sleep(writeStallPollInterval)
+		case l0Files >= lsm.Config.L0SlowdownWritesTrigger:
+			atomic.StoreInt32(&lsm.inWritePaused, 0)
+			time.Sleep(writeSlowdownDelay)
+			atomic.AddInt64(&lsm.cWriteDelay, int64(writeSlowdownDelay))
+			atomic.AddInt64(&lsm.cWriteDelayN, 1)
+			return
+		default:
+			atomic.StoreInt32(&lsm.inWritePaused, 0)
+			return
 		}
 	}
 }
 
-// loadExistingSSTables loads existing SSTable files from disk
-func (lsm *LSMTree) loadExistingSSTables() {
-	files, err := os.ReadDir(lsm.Config.DataDir)
+// checkBackgroundError drains at most one pending error off compErrC, so
+// a flush or compaction failure that happened in the background surfaces
+// on the next Put/Get instead of only ever reaching a log line.
+func (lsm *LSMTree) checkBackgroundError() error {
+	select {
+	case err := <-lsm.compErrC:
+		return err
+	default:
+		return nil
+	}
+}
+
+// rotateMemtableLocked freezes the active memtable into frozenMem and
+// rotates its WAL journal into frozenJournalFile, then wakes
+// memtableCompactionLoop to flush it to an SSTable in the background
+// while a fresh memtable keeps accepting writes. Callers must already
+// hold lsm.mutex for writing, and must only call this when frozenMem is
+// nil — throttleWrite's write-stall guarantees that by blocking Put
+// until the previous freeze has been flushed.
+func (lsm *LSMTree) rotateMemtableLocked() {
+	frozenPath, err := lsm.wal.Rotate()
 	if err != nil {
+		log.Printf("Error rotating WAL journal for memtable freeze: %v", err)
+		return
+	}
+
+	lsm.frozenMem = lsm.Memtable
+	lsm.frozenJournalFile = frozenPath
+	lsm.Memtable = NewMemtable(lsm.Config.MaxMemtableSize, lsm.comparator)
+
+	select {
+	case lsm.mcompCmdC <- struct{}{}:
+	default:
+	}
+}
+
+// memtableCompactionLoop flushes frozenMem to a new L0 SSTable whenever
+// rotateMemtableLocked wakes it, running the Parquet write and MANIFEST
+// append without holding lsm.mutex so Puts against the fresh memtable
+// aren't blocked on that I/O. A failure is posted to compErrC rather than
+// only logged, and frozenMem/frozenJournalFile stay set (so the data
+// isn't lost and a later retry or restart-replay can still recover it)
+// until a flush actually succeeds.
+func (lsm *LSMTree) memtableCompactionLoop() {
+	for {
+		select {
+		case <-lsm.mcompCmdC:
+			lsm.flushFrozenMem()
+		case <-lsm.stopCompaction:
+			return
+		}
+	}
+}
+
+// flushFrozenMem performs one flush of frozenMem to an SSTable, clears
+// the frozen slot and its journal on success, and nudges
+// tableCompactionLoop since a new L0 file may now be over threshold.
+func (lsm *LSMTree) flushFrozenMem() {
+	if !atomic.CompareAndSwapInt32(&lsm.flushingFrozen, 0, 1) {
+		return // another goroutine is already flushing frozenMem
+	}
+	defer atomic.StoreInt32(&lsm.flushingFrozen, 0)
+
+	lsm.mutex.RLock()
+	imm := lsm.frozenMem
+	journal := lsm.frozenJournalFile
+	lsm.mutex.RUnlock()
+
+	if imm == nil {
 		return
 	}
 
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".parquet") && strings.Contains(file.Name(), "sstable") {
-			filename := filepath.Join(lsm.Config.DataDir, file.Name())
-			sstable := NewSSTable(filename)
-			lsm.SSTables = append(lsm.SSTables, sstable)
+	entries := imm.Snapshot()
+	var sstable *SSTable
+	if len(entries) > 0 {
+		filename := filepath.Join(lsm.Config.DataDir, fmt.Sprintf("sstable_L0_%d.parquet", time.Now().UnixNano()))
+		var err error
+		sstable, err = WriteSSTable(entries, filename, 0)
+		if err != nil {
+			lsm.postBackgroundError(fmt.Errorf("flush frozen memtable: %w", err))
+			return
 		}
 	}
+
+	lsm.mutex.Lock()
+	if sstable != nil {
+		lsm.placeInLevel(sstable, 0)
+		if err := appendManifestRecord(lsm.Config.DataDir, newManifestAddRecord(sstable)); err != nil {
+			log.Printf("Error appending MANIFEST record: %v", err)
+		}
+	}
+	lsm.frozenMem = nil
+	lsm.frozenJournalFile = ""
+	lsm.mutex.Unlock()
+
+	if err := lsm.wal.ClearFile(journal); err != nil {
+		log.Printf("Error clearing flushed frozen journal %s: %v", journal, err)
+	}
+
+	select {
+	case lsm.tcompCmdC <- struct{}{}:
+	default:
+	}
+}
+
+// postBackgroundError delivers err on compErrC for the next Put/Get to
+// pick up, replacing whatever error (if any) was already waiting there,
+// since the newer failure is the more actionable one.
+func (lsm *LSMTree) postBackgroundError(err error) {
+	log.Printf("LSM background error: %v", err)
+	select {
+	case <-lsm.compErrC:
+	default:
+	}
+	select {
+	case lsm.compErrC <- err:
+	default:
+	}
+}
+
+// flushActiveMemtableSync is Close's final drain: it freezes whatever is
+// left in the active memtable (if anything) and flushes it synchronously,
+// bypassing the background mcompCmdC handoff since no more writes are
+// coming and Close needs the data durable before it returns.
+func (lsm *LSMTree) flushActiveMemtableSync() {
+	lsm.mutex.Lock()
+	if len(lsm.Memtable.data) > 0 && lsm.frozenMem == nil {
+		lsm.rotateMemtableLocked()
+	}
+	lsm.mutex.Unlock()
+
+	// Poll rather than assume a single call to flushFrozenMem suffices:
+	// memtableCompactionLoop may already be mid-flush for this same
+	// frozenMem (flushFrozenMem's flushingFrozen guard makes our own call
+	// a no-op in that case), so wait for whichever goroutine is doing the
+	// work to actually finish before Close proceeds.
+	for {
+		lsm.mutex.RLock()
+		pending := lsm.frozenMem != nil
+		lsm.mutex.RUnlock()
+		if !pending {
+			return
+		}
+		lsm.flushFrozenMem()
+		time.Sleep(writeStallPollInterval)
+	}
 }
 
 // GetStats returns LSM tree statistics
@@ -627,11 +1625,29 @@ func (lsm *LSMTree) GetStats() map[string]int64 {
 	lsm.mutex.RLock()
 	defer lsm.mutex.RUnlock()
 
+	var sstablesCount int64
+	for _, level := range lsm.Levels {
+		sstablesCount += int64(len(level))
+	}
+
+	frozenPending := int64(0)
+	if lsm.frozenMem != nil {
+		frozenPending = 1
+	}
+
+	writePaused := int64(0)
+	if atomic.LoadInt32(&lsm.inWritePaused) != 0 {
+		writePaused = 1
+	}
+
 	return map[string]int64{
 		"memtable_entries":    int64(len(lsm.Memtable.data)),
 		"memtable_size_bytes": int64(lsm.Memtable.size),
-		"immutable_memtables": int64(len(lsm.ImmutableMemtables)),
-		"sstables_count":      int64(len(lsm.SSTables)),
+		"frozen_memtable":     frozenPending,
+		"sstables_count":      sstablesCount,
+		"c_write_delay_nanos": atomic.LoadInt64(&lsm.cWriteDelay),
+		"c_write_delay_count": atomic.LoadInt64(&lsm.cWriteDelayN),
+		"in_write_paused":     writePaused,
 	}
 }
 
@@ -643,6 +1659,44 @@ type LSMDatabase struct {
 	Metrics    *DatabaseMetrics
 	IsOpen     bool
 	mutex      sync.RWMutex
+
+	// writeQueueMu guards writeQueue, the pending-writer list a Write
+	// call enqueues itself onto so N concurrent callers share a single
+	// WAL append and memtable-apply pass instead of paying for N of
+	// each - the leader (whichever call found the queue empty) drains
+	// the whole queue once it has db.mutex. See Write and writeLocked.
+	writeQueueMu sync.Mutex
+	writeQueue   []*writeMerge
+
+	// lastRecovery is the report produced by the most recent
+	// recoverFromWAL call, nil until the first one runs. See Recover.
+	lastRecovery *RecoveryReport
+}
+
+// RecoveryReport summarizes what CreateDatabase's startup recovery did:
+// how many operations each replayed journal file contributed, how many
+// trailing bytes were discarded across all of them because a record was
+// torn or failed its checksum, and the highest sequence number any of
+// them recovered.
+type RecoveryReport struct {
+	PerFileReplayed map[string]int
+	BytesSkipped    int64
+	HighestSeq      int64
+}
+
+// Recover returns the report from the most recent startup recovery, or
+// nil if none was produced (WAL disabled, or recovery failed). A startup
+// with nothing to replay still yields a report, with zero counts.
+func (db *LSMDatabase) Recover() *RecoveryReport {
+	return db.lastRecovery
+}
+
+// writeMerge is one Write call's batch, enqueued on writeQueue for
+// whichever goroutine leads the current commit round. writeMergedC
+// carries the shared commit's result back to the enqueuing goroutine.
+type writeMerge struct {
+	batch        *WriteBatch
+	writeMergedC chan error
 }
 
 // NewLSMDatabase creates a new LSM database
@@ -651,10 +1705,15 @@ func NewLSMDatabase(config *DatabaseConfig) (*LSMDatabase, error) {
 		return nil, err
 	}
 
+	walManager := NewWALManager(config.DataDir, config.Name, config.EnableWAL)
+	lsmTree, err := NewLSMTree(&config.LSMConfig, walManager)
+	if err != nil {
+		return nil, err
+	}
 	db := &LSMDatabase{
 		Config:     config,
-		LSMTree:    NewLSMTree(&config.LSMConfig),
-		WALManager: NewWALManager(config.DataDir, config.Name, config.EnableWAL),
+		LSMTree:    lsmTree,
+		WALManager: walManager,
 		Metrics:    NewDatabaseMetrics(),
 		IsOpen:     true,
 	}
@@ -688,7 +1747,9 @@ func (db *LSMDatabase) Put(key, value string) error {
 	}
 
 	// Perform operation
-	db.LSMTree.Put(key, value)
+	if err := db.LSMTree.Put(key, value, walEntry.SequenceNumber); err != nil {
+		return err
+	}
 
 	// Update metrics
 	if db.Config.EnableMetrics {
@@ -708,7 +1769,10 @@ func (db *LSMDatabase) Get(key string) (string, error) {
 	}
 
 	// Perform operation
-	value := db.LSMTree.Get(key)
+	value, err := db.LSMTree.Get(key)
+	if err != nil {
+		return "", err
+	}
 
 	// Update metrics
 	if db.Config.EnableMetrics {
@@ -734,7 +1798,9 @@ func (db *LSMDatabase) Delete(key string) error {
 	}
 
 	// Perform operation
-	db.LSMTree.Delete(key)
+	if err := db.LSMTree.Delete(key, walEntry.SequenceNumber); err != nil {
+		return err
+	}
 
 	// Update metrics
 	if db.Config.EnableMetrics {
@@ -764,6 +1830,10 @@ func (db *LSMDatabase) GetStats() (map[string]int64, error) {
 	combinedStats["lsm_memtable_entries"] = lsmStats["memtable_entries"]
 	combinedStats["lsm_memtable_size"] = lsmStats["memtable_size_bytes"]
 	combinedStats["lsm_sstables_count"] = lsmStats["sstables_count"]
+	combinedStats["lsm_frozen_memtable"] = lsmStats["frozen_memtable"]
+	combinedStats["lsm_write_delay_nanos"] = lsmStats["c_write_delay_nanos"]
+	combinedStats["lsm_write_delay_count"] = lsmStats["c_write_delay_count"]
+	combinedStats["lsm_write_paused"] = lsmStats["in_write_paused"]
 
 	return combinedStats, nil
 }
@@ -779,11 +1849,10 @@ func (db *LSMDatabase) Close() error {
 
 	fmt.Printf("Closing LSM Database '%s'...\n", db.Config.Name)
 
-	// Force final memtable flush if needed
-	stats := db.LSMTree.GetStats()
-	if stats["memtable_entries"] > 0 {
-		db.LSMTree.flushMemtable()
-	}
+	// Freeze and flush whatever's left in the active memtable
+	// synchronously, since Close needs it durable before it returns.
+	db.LSMTree.flushActiveMemtableSync()
+	db.LSMTree.Close()
 
 	// Clear WAL after successful operations
 	if db.Config.EnableWAL {
@@ -797,36 +1866,187 @@ func (db *LSMDatabase) Close() error {
 	return nil
 }
 
-// recoverFromWAL recovers database state from WAL entries
+// recoverFromWAL recovers database state from WAL entries and batches.
+// A frozen journal left behind by a crash mid-flush (see
+// rotateMemtableLocked) predates the active WAL's entries, so it's
+// replayed first and then removed, the same way a successful flush
+// would have cleaned it up.
 func (db *LSMDatabase) recoverFromWAL() error {
 	if !db.Config.EnableWAL {
 		return nil
 	}
 
 	fmt.Println("Recovering from WAL...")
-	walEntries, err := db.WALManager.GetEntries()
+	report := &RecoveryReport{PerFileReplayed: make(map[string]int)}
+
+	applyEntry := func(entry *WALEntry) {
+		var err error
+		switch entry.Operation {
+		case "PUT":
+			err = db.LSMTree.Put(entry.Key, entry.Value, entry.SequenceNumber)
+		case "DELETE":
+			err = db.LSMTree.Delete(entry.Key, entry.SequenceNumber)
+		}
+		if err != nil {
+			log.Printf("Error replaying WAL entry: %v", err)
+		}
+	}
+	applyBatch := func(batch *WriteBatch, seqStart int64) {
+		for i := 0; i < batch.Len(); i++ {
+			op := batch.Op(i)
+			seq := seqStart + int64(i)
+			var err error
+			switch op.Operation {
+			case "PUT":
+				err = db.LSMTree.Put(op.Key, op.Value, seq)
+			case "DELETE":
+				err = db.LSMTree.Delete(op.Key, seq)
+			}
+			if err != nil {
+				log.Printf("Error replaying WAL batch op: %v", err)
+			}
+		}
+	}
+
+	frozenJournal := db.WALManager.WALFile + ".frozen"
+	if _, err := os.Stat(frozenJournal); err == nil {
+		fmt.Println("Found a frozen journal from an interrupted flush, replaying it first...")
+		fr, err := db.WALManager.ReplayFile(frozenJournal, applyEntry, applyBatch)
+		if err != nil {
+			return err
+		}
+		report.PerFileReplayed[frozenJournal] = fr.Applied
+		report.BytesSkipped += fr.BytesSkipped
+		if fr.HighestSeq > report.HighestSeq {
+			report.HighestSeq = fr.HighestSeq
+		}
+		if err := db.WALManager.ClearFile(frozenJournal); err != nil {
+			log.Printf("Warning: failed to clear recovered frozen journal: %v", err)
+		}
+	}
+
+	wr, err := db.WALManager.Replay(applyEntry, applyBatch)
 	if err != nil {
 		return err
 	}
+	report.PerFileReplayed[db.WALManager.WALFile] = wr.Applied
+	report.BytesSkipped += wr.BytesSkipped
+	if wr.HighestSeq > report.HighestSeq {
+		report.HighestSeq = wr.HighestSeq
+	}
+
+	db.lastRecovery = report
 
-	if len(walEntries) == 0 {
+	replayed := 0
+	for _, n := range report.PerFileReplayed {
+		replayed += n
+	}
+	if replayed == 0 && report.BytesSkipped == 0 {
 		fmt.Println("No WAL entries to recover")
 		return nil
 	}
 
-	fmt.Printf("Replaying %d WAL entries...\n", len(walEntries))
+	fmt.Printf("Replayed %d WAL operations (%d bytes skipped to torn/bad-checksum tail)\n", replayed, report.BytesSkipped)
+	fmt.Println("Recovery complete")
+	return nil
+}
 
-	for _, entry := range walEntries {
-		switch entry.Operation {
+// Write commits an entire WriteBatch atomically: the batch is appended to
+// the WAL as a single fsync'd record, and only after that succeeds are
+// its operations applied to the memtable under one lock acquisition, so
+// readers never observe a partially-applied batch.
+//
+// Concurrent Write callers are merged goleveldb-style instead of each
+// paying for their own WAL append and memtable pass: a caller enqueues
+// its batch on writeQueue under writeQueueMu; if the queue was empty
+// before it enqueued, it leads the next commit round itself, otherwise
+// it just waits on writeMergedC for the leader's shared result. The
+// leader only drains writeQueue once it actually holds db.mutex, so
+// every caller that queued up while the leader was waiting for the lock
+// - not just the ones lucky enough to race a narrow rendezvous window -
+// gets folded into that round.
+func (db *LSMDatabase) Write(batch *WriteBatch) error {
+	req := &writeMerge{batch: batch, writeMergedC: make(chan error, 1)}
+
+	db.writeQueueMu.Lock()
+	db.writeQueue = append(db.writeQueue, req)
+	isLeader := len(db.writeQueue) == 1
+	db.writeQueueMu.Unlock()
+
+	if !isLeader {
+		return <-req.writeMergedC
+	}
+
+	return db.writeLocked(req)
+}
+
+// writeLocked leads one commit round on behalf of leaderReq: it takes
+// db's write lock, drains every batch now waiting on writeQueue
+// (leaderReq included, since it enqueued itself before calling this),
+// commits the combined batch as a single WAL append and memtable-apply
+// pass, and hands every participant its result over writeMergedC.
+func (db *LSMDatabase) writeLocked(leaderReq *writeMerge) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.writeQueueMu.Lock()
+	participants := db.writeQueue
+	db.writeQueue = nil
+	db.writeQueueMu.Unlock()
+
+	if !db.IsOpen {
+		err := fmt.Errorf("database is closed")
+		for _, p := range participants {
+			p.writeMergedC <- err
+		}
+		return err
+	}
+
+	combined := NewWriteBatch()
+	for _, p := range participants {
+		for i := 0; i < p.batch.Len(); i++ {
+			op := p.batch.Op(i)
+			switch op.Operation {
+			case "PUT":
+				combined.Put(op.Key, op.Value)
+			case "DELETE":
+				combined.Delete(op.Key)
+			}
+		}
+	}
+
+	seqStart, err := db.WALManager.AppendBatch(combined)
+	if err != nil {
+		for _, p := range participants {
+			p.writeMergedC <- err
+		}
+		return err
+	}
+
+	var applyErr error
+	for i := 0; i < combined.Len(); i++ {
+		op := combined.Op(i)
+		seq := seqStart + int64(i)
+		switch op.Operation {
 		case "PUT":
-			db.LSMTree.Put(entry.Key, entry.Value)
+			applyErr = db.LSMTree.Put(op.Key, op.Value, seq)
 		case "DELETE":
-			db.LSMTree.Delete(entry.Key)
+			applyErr = db.LSMTree.Delete(op.Key, seq)
+		}
+		if applyErr != nil {
+			break
+		}
+
+		if db.Config.EnableMetrics {
+			db.Metrics.RecordOperation(op.Operation)
 		}
 	}
 
-	fmt.Println("Recovery complete")
-	return nil
+	for _, p := range participants {
+		p.writeMergedC <- applyErr
+	}
+
+	return <-leaderReq.writeMergedC
 }
 
 // Factory functions for different database configurations
@@ -1053,6 +2273,19 @@ func demoWALRecovery() error {
 
 	fmt.Printf("Recovered entries: %d / 50\n", recoveredCount)
 
+	report := db2.Recover()
+	if report == nil {
+		return fmt.Errorf("expected a recovery report, got none")
+	}
+	if report.BytesSkipped != 0 {
+		return fmt.Errorf("expected a clean shutdown to leave nothing torn, got %d bytes skipped", report.BytesSkipped)
+	}
+	// A clean Close flushes everything to an SSTable and clears the WAL,
+	// so a well-behaved restart replays nothing here; the 50 entries
+	// above were recovered from the MANIFEST-tracked SSTable, not WAL replay.
+	fmt.Printf("Recovery report: per-file replay counts %v, bytes skipped %d, highest seq %d\n",
+		report.PerFileReplayed, report.BytesSkipped, report.HighestSeq)
+
 	stats, err := db2.GetStats()
 	if err != nil {
 		return err
@@ -1064,3 +2297,113 @@ func demoWALRecovery() error {
 	fmt.Println("\nRecovery test completed successfully!")
 	return nil
 }
+
+// demoWriteBatch demonstrates atomic multi-key commits via WriteBatch,
+// then closes and reopens the database and reads the batch back. The
+// clean Close flushes the batch to an SSTable, so the reopened database
+// finds it there rather than replaying it from the WAL.
+func demoWriteBatch() error {
+	fmt.Println("=== WriteBatch Demonstration ===\n")
+
+	dbName := "writebatch_test"
+	dataDir := "./writebatch_test"
+
+	db, err := CreateDatabase(dbName, dataDir, "hash_skiplist")
+	if err != nil {
+		return err
+	}
+
+	batch := NewWriteBatch()
+	for i := 0; i < 20; i++ {
+		batch.Put(fmt.Sprintf("batch_key%d", i), fmt.Sprintf("batch_value%d", i))
+	}
+	batch.Delete("batch_key0")
+	fmt.Printf("Staged batch: %d ops, ~%d bytes\n", batch.Len(), batch.Size())
+
+	if err := db.Write(batch); err != nil {
+		db.Close()
+		return err
+	}
+	fmt.Println("Batch committed atomically")
+	db.Close() // This should clear the WAL
+
+	fmt.Println("\nReopening the database and reading the batch back...")
+	db2, err := CreateDatabase(dbName, dataDir, "hash_skiplist")
+	if err != nil {
+		return err
+	}
+	defer db2.Close()
+
+	recoveredCount := 0
+	for i := 1; i < 20; i++ {
+		value, err := db2.Get(fmt.Sprintf("batch_key%d", i))
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			recoveredCount++
+		}
+	}
+	deletedValue, err := db2.Get("batch_key0")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Recovered entries: %d / 19 (plus 1 deleted key, got %q)\n", recoveredCount, deletedValue)
+	fmt.Println("WriteBatch demo completed successfully!")
+	return nil
+}
+
+// demoConcurrentWrites fires several Write calls from separate goroutines
+// at once, demonstrating that they're merged into shared commit rounds
+// (via writeQueue/writeMergedC) rather than each taking its own WAL
+// fsync and memtable pass, while every key still ends up durably written.
+func demoConcurrentWrites() error {
+	fmt.Println("=== Concurrent WriteBatch Demonstration ===\n")
+
+	dbName := "concurrent_writebatch_test"
+	dataDir := "./concurrent_writebatch_test"
+
+	db, err := CreateDatabase(dbName, dataDir, "hash_skiplist")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			batch := NewWriteBatch()
+			batch.Put(fmt.Sprintf("concurrent_key%d", g), fmt.Sprintf("concurrent_value%d", g))
+			errs <- db.Write(batch)
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	written := 0
+	for g := 0; g < goroutines; g++ {
+		value, err := db.Get(fmt.Sprintf("concurrent_key%d", g))
+		if err != nil {
+			return err
+		}
+		if value == fmt.Sprintf("concurrent_value%d", g) {
+			written++
+		}
+	}
+
+	fmt.Printf("%d/%d concurrent writers committed successfully\n", written, goroutines)
+	fmt.Println("Concurrent WriteBatch demo completed successfully!")
+	return nil
+}
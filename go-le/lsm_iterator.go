@@ -0,0 +1,598 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// Iterator yields keys in sorted order over a merged, point-in-time view
+// of an LSM tree's memtable, immutable memtables, and SSTables.
+type Iterator interface {
+	First() bool
+	Last() bool
+	Seek(key string) bool
+	Next() bool
+	Valid() bool
+	Key() string
+	Value() string
+	Close() error
+}
+
+// iterSource is one sorted input to the k-way merge. rank orders sources
+// by recency, used only as a heap tie-break on pop order; the actual
+// winner among entries sharing a key is the one with the highest seq()
+// visible to the merge's snapshot bound, so every source sharing that
+// key is silently advanced past it regardless of rank.
+type iterSource interface {
+	rank() int
+	open() error
+	valid() bool
+	key() string
+	value() (string, bool) // value, deleted
+	seq() int64
+	advance() error
+	close() error
+}
+
+// memSource is a sorted, in-memory snapshot of a memtable or immutable
+// memtable.
+type memSource struct {
+	entries []MemtableEntry
+	idx     int
+	r       int
+}
+
+func newMemSource(entries []MemtableEntry, rank int) *memSource {
+	return &memSource{entries: entries, r: rank}
+}
+
+func (s *memSource) rank() int    { return s.r }
+func (s *memSource) open() error  { s.idx = 0; return nil }
+func (s *memSource) valid() bool  { return s.idx < len(s.entries) }
+func (s *memSource) key() string  { return s.entries[s.idx].Key }
+func (s *memSource) value() (string, bool) {
+	e := s.entries[s.idx]
+	return e.Value, e.Deleted
+}
+func (s *memSource) seq() int64     { return s.entries[s.idx].Seq }
+func (s *memSource) advance() error { s.idx++; return nil }
+func (s *memSource) close() error   { return nil }
+
+// seekTo advances the cursor to the first entry >= key, via binary search
+// since entries are already sorted.
+func (s *memSource) seekTo(key string) {
+	s.idx = sort.Search(len(s.entries), func(i int) bool { return s.entries[i].Key >= key })
+}
+
+// sstSource streams an SSTable's rows in row-group batches via
+// pqarrow.FileReader.GetRecordReader, rather than materializing the whole
+// table, since a k-way merge only ever needs the current row of each
+// source at a time.
+type sstSource struct {
+	sst    *SSTable
+	r      int
+	f      *os.File
+	pr     *file.Reader
+	rr     pqarrow.RecordReader
+	batch  arrow.Record
+	keyCol *array.String
+	valCol *array.String
+	delCol *array.Boolean
+	seqCol *array.Int64
+	row    int
+	done   bool
+}
+
+func newSSTSource(sst *SSTable, rank int) *sstSource {
+	return &sstSource{sst: sst, r: rank}
+}
+
+func (s *sstSource) rank() int { return s.r }
+
+// open (re)opens the Parquet file from the start and loads its first
+// batch, so repeated First()/Seek() calls get a fresh streaming reader.
+func (s *sstSource) open() error {
+	s.closeReader()
+
+	f, err := os.Open(s.sst.Filename)
+	if err != nil {
+		return err
+	}
+	pr, err := file.NewParquetReader(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	arrowReader, err := pqarrow.NewFileReader(pr, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		pr.Close()
+		f.Close()
+		return err
+	}
+	rr, err := arrowReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		pr.Close()
+		f.Close()
+		return err
+	}
+
+	s.f, s.pr, s.rr = f, pr, rr
+	s.batch, s.row, s.done = nil, 0, false
+	return s.loadNextBatch()
+}
+
+// loadNextBatch pulls the next row-group batch from the record reader,
+// releasing the previous one first.
+func (s *sstSource) loadNextBatch() error {
+	if s.batch != nil {
+		s.batch.Release()
+		s.batch = nil
+	}
+
+	if !s.rr.Next() {
+		if err := s.rr.Err(); err != nil {
+			return err
+		}
+		s.done = true
+		return nil
+	}
+
+	batch := s.rr.Record()
+	keyArr, ok := batch.Column(0).(*array.String)
+	if !ok {
+		return fmt.Errorf("sstable %s: key column is not string", s.sst.Filename)
+	}
+	valArr, ok := batch.Column(1).(*array.String)
+	if !ok {
+		return fmt.Errorf("sstable %s: value column is not string", s.sst.Filename)
+	}
+	delArr, ok := batch.Column(2).(*array.Boolean)
+	if !ok {
+		return fmt.Errorf("sstable %s: deleted column is not boolean", s.sst.Filename)
+	}
+	seqArr, ok := batch.Column(3).(*array.Int64)
+	if !ok {
+		return fmt.Errorf("sstable %s: seq column is not int64", s.sst.Filename)
+	}
+
+	s.batch, s.keyCol, s.valCol, s.delCol, s.seqCol, s.row = batch, keyArr, valArr, delArr, seqArr, 0
+	return nil
+}
+
+func (s *sstSource) valid() bool { return !s.done }
+func (s *sstSource) key() string { return s.keyCol.Value(s.row) }
+func (s *sstSource) value() (string, bool) {
+	return s.valCol.Value(s.row), s.delCol.Value(s.row)
+}
+func (s *sstSource) seq() int64 { return s.seqCol.Value(s.row) }
+
+func (s *sstSource) advance() error {
+	s.row++
+	if s.batch == nil || s.row >= int(s.batch.NumRows()) {
+		return s.loadNextBatch()
+	}
+	return nil
+}
+
+// seekTo reopens the stream and skips forward to the first row with a
+// key >= target, relying on the file being sorted by key.
+func (s *sstSource) seekTo(key string) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	for s.valid() && s.key() < key {
+		if err := s.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sstSource) closeReader() {
+	if s.batch != nil {
+		s.batch.Release()
+		s.batch = nil
+	}
+	if s.rr != nil {
+		s.rr.Release()
+		s.rr = nil
+	}
+	if s.pr != nil {
+		s.pr.Close()
+		s.pr = nil
+	}
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+}
+
+func (s *sstSource) close() error {
+	s.closeReader()
+	return nil
+}
+
+// sourceHeap is a min-heap over active sources, ordered by current key
+// and, on ties, by rank (lower rank = more recent = popped first).
+type sourceHeap []iterSource
+
+func (h sourceHeap) Len() int { return len(h) }
+func (h sourceHeap) Less(i, j int) bool {
+	ki, kj := h[i].key(), h[j].key()
+	if ki != kj {
+		return ki < kj
+	}
+	return h[i].rank() < h[j].rank()
+}
+func (h sourceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sourceHeap) Push(x any)        { *h = append(*h, x.(iterSource)) }
+func (h *sourceHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// LSMIterator is a k-way min-heap merge over a memtable snapshot, the
+// immutable memtables, and all SSTables, all ranked newest-to-oldest.
+// On a duplicate key the version with the highest sequence number not
+// exceeding maxSeq wins; every other version of that key, in every
+// source, is advanced without being surfaced, so deletes (tombstones)
+// correctly shadow older values and a snapshot never sees a write that
+// postdates it.
+type LSMIterator struct {
+	sources   []iterSource
+	h         sourceHeap
+	lower     string
+	upper     string
+	hasLow    bool
+	hasHigh   bool
+	maxSeq    int64
+	hasMaxSeq bool
+
+	key     string
+	value   string
+	isValid bool
+}
+
+// NewIterator returns an Iterator over lsm's current memtable, immutable
+// memtables, and SSTables, bounded to the half-open range [lower, upper)
+// when lower/upper are non-empty, reading the latest version of every
+// key.
+func (lsm *LSMTree) NewIterator(lower, upper string) *LSMIterator {
+	return lsm.newIterator(lower, upper, 0, false)
+}
+
+// NewIteratorAt is like NewIterator, but restricted to the point-in-time
+// view a snapshot at maxSeq saw: a key version written after maxSeq is
+// invisible, and the search falls through to whatever version (if any)
+// was visible as of maxSeq instead.
+func (lsm *LSMTree) NewIteratorAt(lower, upper string, maxSeq int64) *LSMIterator {
+	return lsm.newIterator(lower, upper, maxSeq, true)
+}
+
+func (lsm *LSMTree) newIterator(lower, upper string, maxSeq int64, hasMaxSeq bool) *LSMIterator {
+	lsm.mutex.RLock()
+	defer lsm.mutex.RUnlock()
+
+	var sources []iterSource
+	rank := 0
+
+	sources = append(sources, newMemSource(lsm.Memtable.Snapshot(), rank))
+	rank++
+
+	if lsm.frozenMem != nil {
+		sources = append(sources, newMemSource(lsm.frozenMem.Snapshot(), rank))
+		rank++
+	}
+
+	if len(lsm.Levels) > 0 {
+		l0 := lsm.Levels[0]
+		for i := len(l0) - 1; i >= 0; i-- {
+			sources = append(sources, newSSTSource(l0[i], rank))
+			rank++
+		}
+	}
+	for level := 1; level < len(lsm.Levels); level++ {
+		for _, sst := range lsm.Levels[level] {
+			sources = append(sources, newSSTSource(sst, rank))
+			rank++
+		}
+	}
+
+	return &LSMIterator{
+		sources:   sources,
+		lower:     lower,
+		upper:     upper,
+		hasLow:    lower != "",
+		hasHigh:   upper != "",
+		maxSeq:    maxSeq,
+		hasMaxSeq: hasMaxSeq,
+	}
+}
+
+// NewIterator returns an Iterator over db's underlying LSM tree.
+func (db *LSMDatabase) NewIterator(lower, upper string) (*LSMIterator, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if !db.IsOpen {
+		return nil, fmt.Errorf("database is closed")
+	}
+	return db.LSMTree.NewIterator(lower, upper), nil
+}
+
+// RangeScan returns an Iterator over the half-open byte-string range
+// [start, limit). An empty limit means "no upper bound". It's the same
+// iterator NewIterator returns, just taking []byte to match a
+// Comparator's key type rather than this package's usual string keys.
+func (db *LSMDatabase) RangeScan(start, limit []byte) (*LSMIterator, error) {
+	return db.NewIterator(string(start), string(limit))
+}
+
+// PrefixScan returns an Iterator over every key starting with prefix.
+// The upper bound is prefix with its last non-0xff byte incremented and
+// everything after it dropped (e.g. "ab" -> "ac"), the tightest bound
+// that still excludes every key NOT starting with prefix; if prefix is
+// all 0xff bytes (or empty) there's no such bound, so the scan runs to
+// the end of the keyspace instead.
+func (db *LSMDatabase) PrefixScan(prefix []byte) (*LSMIterator, error) {
+	return db.RangeScan(prefix, prefixUpperBound(prefix))
+}
+
+// prefixUpperBound returns the smallest key greater than every key
+// prefixed by prefix, or nil if no such (finite) key exists.
+func prefixUpperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			bound := append([]byte{}, prefix[:i+1]...)
+			bound[i]++
+			return bound
+		}
+	}
+	return nil
+}
+
+// resetHeap rebuilds the merge heap from each source's current position.
+func (it *LSMIterator) resetHeap() {
+	it.h = it.h[:0]
+	for _, src := range it.sources {
+		if src.valid() {
+			it.h = append(it.h, src)
+		}
+	}
+	heap.Init(&it.h)
+}
+
+// First positions the iterator at the first live key >= the lower bound.
+func (it *LSMIterator) First() bool {
+	for _, src := range it.sources {
+		if err := src.open(); err != nil {
+			it.isValid = false
+			return false
+		}
+	}
+	if it.hasLow {
+		return it.Seek(it.lower)
+	}
+	it.resetHeap()
+	return it.advanceToNextLive()
+}
+
+// Last positions the iterator at the last live key < the upper bound.
+// Streaming sources only support forward iteration efficiently, so Last
+// is implemented by scanning to the end once and remembering the final
+// live entry seen.
+func (it *LSMIterator) Last() bool {
+	if !it.First() {
+		return false
+	}
+	lastKey, lastValue := it.key, it.value
+	for it.Next() {
+		lastKey, lastValue = it.key, it.value
+	}
+	it.key, it.value, it.isValid = lastKey, lastValue, true
+	return true
+}
+
+// Seek repositions the iterator at the first live key >= key.
+func (it *LSMIterator) Seek(key string) bool {
+	for _, src := range it.sources {
+		switch s := src.(type) {
+		case *memSource:
+			s.seekTo(key)
+		case *sstSource:
+			if err := s.seekTo(key); err != nil {
+				it.isValid = false
+				return false
+			}
+		}
+	}
+	it.resetHeap()
+	return it.advanceToNextLive()
+}
+
+// Next advances to the next live key.
+func (it *LSMIterator) Next() bool {
+	if !it.isValid {
+		return false
+	}
+	return it.advanceToNextLive()
+}
+
+// advanceToNextLive pops every version of the smallest key off the merge
+// heap, advances every source that shared that key so they don't
+// reappear, and picks the winning version: the highest sequence number
+// not exceeding maxSeq (when bounded), so a snapshot read falls through
+// to whatever version it could actually see instead of one written
+// after it. It then skips tombstones and out-of-range keys until a live
+// entry surfaces or every source is exhausted.
+func (it *LSMIterator) advanceToNextLive() bool {
+	for it.h.Len() > 0 {
+		winKey := it.h[0].key()
+
+		var winValue string
+		var winDeleted bool
+		var winSeq int64
+		haveWin := false
+
+		for it.h.Len() > 0 && it.h[0].key() == winKey {
+			src := heap.Pop(&it.h).(iterSource)
+
+			v, deleted := src.value()
+			s := src.seq()
+			if !it.hasMaxSeq || s <= it.maxSeq {
+				if !haveWin || s > winSeq {
+					winValue, winDeleted, winSeq, haveWin = v, deleted, s, true
+				}
+			}
+
+			if err := src.advance(); err != nil {
+				it.isValid = false
+				return false
+			}
+			if src.valid() {
+				heap.Push(&it.h, src)
+			}
+		}
+
+		if it.hasHigh && winKey >= it.upper {
+			it.isValid = false
+			return false
+		}
+		if it.hasLow && winKey < it.lower {
+			continue
+		}
+		if !haveWin || winDeleted {
+			continue
+		}
+
+		it.key, it.value, it.isValid = winKey, winValue, true
+		return true
+	}
+
+	it.isValid = false
+	return false
+}
+
+// Valid reports whether the iterator is positioned at a live entry.
+func (it *LSMIterator) Valid() bool { return it.isValid }
+
+// Key returns the current entry's key. Only valid when Valid() is true.
+func (it *LSMIterator) Key() string { return it.key }
+
+// Value returns the current entry's value. Only valid when Valid() is true.
+func (it *LSMIterator) Value() string { return it.value }
+
+// Close releases every source's underlying file handles.
+func (it *LSMIterator) Close() error {
+	var firstErr error
+	for _, src := range it.sources {
+		if err := src.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// demoLSMIterator demonstrates a range scan over the merged view,
+// including a deleted key correctly disappearing from it.
+func demoLSMIterator() error {
+	fmt.Println("=== LSM Iterator Demonstration ===\n")
+
+	dbName := "iterator_test"
+	dataDir := "./iterator_test"
+
+	db, err := CreateDatabase(dbName, dataDir, "hash_skiplist")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := db.Put(fmt.Sprintf("iter_key%02d", i), fmt.Sprintf("iter_value%d", i)); err != nil {
+			return err
+		}
+	}
+	if err := db.Delete("iter_key05"); err != nil {
+		return err
+	}
+
+	it, err := db.NewIterator("iter_key02", "iter_key08")
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	fmt.Println("Scanning range [iter_key02, iter_key08):")
+	count := 0
+	for valid := it.First(); valid; valid = it.Next() {
+		fmt.Printf("  %s = %s\n", it.Key(), it.Value())
+		count++
+	}
+	fmt.Printf("Scanned %d live keys (iter_key05 should be absent, deleted)\n", count)
+
+	return nil
+}
+
+// demoPrefixRangeScan demonstrates RangeScan and PrefixScan against a
+// mix of keys under two different prefixes.
+func demoPrefixRangeScan() error {
+	fmt.Println("=== Prefix/Range Scan Demonstration ===\n")
+
+	dbName := "prefix_scan_test"
+	dataDir := "./prefix_scan_test"
+
+	db, err := CreateDatabase(dbName, dataDir, "hash_skiplist")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := db.Put(fmt.Sprintf("user:%02d", i), fmt.Sprintf("user_value%d", i)); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := db.Put(fmt.Sprintf("order:%02d", i), fmt.Sprintf("order_value%d", i)); err != nil {
+			return err
+		}
+	}
+
+	it, err := db.PrefixScan([]byte("user:"))
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	count := 0
+	for valid := it.First(); valid; valid = it.Next() {
+		count++
+	}
+	fmt.Printf("PrefixScan(\"user:\") saw %d keys (expected 5, orders excluded)\n", count)
+
+	rit, err := db.RangeScan([]byte("user:01"), []byte("user:03"))
+	if err != nil {
+		return err
+	}
+	defer rit.Close()
+
+	rcount := 0
+	for valid := rit.First(); valid; valid = rit.Next() {
+		rcount++
+	}
+	fmt.Printf("RangeScan([\"user:01\", \"user:03\")) saw %d keys (expected 2)\n", rcount)
+
+	return nil
+}
@@ -56,6 +56,206 @@ func exampleMerkleTree() {
 	fmt.Print(tree.VisualizeTree())
 }
 
+// Example: Sparse Merkle Tree
+func exampleSparseMerkleTree() {
+	smt := NewSparseMerkleTree()
+
+	accounts := []struct {
+		key   string
+		value string
+	}{
+		{"account:alice", "balance:100"},
+		{"account:bob", "balance:50"},
+		{"account:carol", "balance:75"},
+	}
+
+	fmt.Printf("Building sparse Merkle tree with %d accounts...\n", len(accounts))
+	for _, acc := range accounts {
+		smt.Update([]byte(acc.key), []byte(acc.value))
+	}
+	fmt.Printf("Root hash: %s\n", smt.GetRootHash())
+
+	fmt.Println("\n--- Membership Proofs ---")
+	for _, acc := range accounts {
+		proof := smt.GenerateProof([]byte(acc.key))
+		valid := VerifySMTProof(smt.GetRootHash(), proof)
+		fmt.Printf("%s -> %s: membership=%v valid=%v\n", acc.key, acc.value, proof.Membership, valid)
+	}
+
+	fmt.Println("\n--- Non-Membership Proof ---")
+	missingKey := "account:dave"
+	proof := smt.GenerateProof([]byte(missingKey))
+	valid := VerifySMTProof(smt.GetRootHash(), proof)
+	fmt.Printf("%s: membership=%v valid=%v\n", missingKey, proof.Membership, valid)
+
+	fmt.Println("\n--- Delete and Re-Verify ---")
+	smt.Delete([]byte("account:bob"))
+	proof = smt.GenerateProof([]byte("account:bob"))
+	valid = VerifySMTProof(smt.GetRootHash(), proof)
+	fmt.Printf("account:bob after delete: membership=%v valid=%v\n", proof.Membership, valid)
+}
+
+// Example: Persistent Merkle Tree backed by pluggable storage
+func examplePersistentMerkleTree() {
+	dataBlocks := []string{
+		"block_0: transaction A",
+		"block_1: transaction B",
+		"block_2: transaction C",
+		"block_3: transaction D",
+	}
+
+	storage := NewMemStorage()
+	tree := NewPersistentMerkleTree(storage, "sha256")
+
+	fmt.Printf("Building persistent Merkle tree with %d data blocks...\n", len(dataBlocks))
+	if err := tree.Build(dataBlocks); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	rootHash, _ := tree.GetRootHash()
+	fmt.Printf("Root hash: %s\n", rootHash)
+
+	proof, err := tree.GetProof(1)
+	if err != nil {
+		fmt.Printf("Error generating proof: %v\n", err)
+		return
+	}
+	fmt.Printf("Proof for leaf [1]: %d siblings\n", len(proof))
+
+	fmt.Println("\n--- Adding a new block through a transaction ---")
+	if err := tree.Add("block_4: transaction E"); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	newRootHash, _ := tree.GetRootHash()
+	fmt.Printf("Root hash after Add: %s\n", newRootHash)
+}
+
+// Example: building the same data with different pluggable hashers
+func examplePluggableHashers() {
+	dataBlocks := []string{"block_0: transaction A", "block_1: transaction B", "block_2: transaction C"}
+
+	for _, hasherName := range []string{"sha256", "sha3-256", "keccak256", "blake2b", "poseidon"} {
+		tree, err := NewMerkleTree(dataBlocks, hasherName)
+		if err != nil {
+			fmt.Printf("%s: error: %v\n", hasherName, err)
+			continue
+		}
+		fmt.Printf("%-10s root: %s\n", hasherName, tree.GetRootHash())
+	}
+}
+
+// Example: batch-verifying a subset of leaves with a single multiproof
+func exampleMultiProof() {
+	dataBlocks := []string{
+		"block_0: transaction A",
+		"block_1: transaction B",
+		"block_2: transaction C",
+		"block_3: transaction D",
+		"block_4: transaction E",
+	}
+
+	tree, err := NewMerkleTree(dataBlocks, "sha256")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	indices := []int{0, 2, 4}
+	multiProof := tree.GetMultiProof(indices)
+	fmt.Printf("Multiproof for leaves %v: %d proof hashes, %d flags\n", indices, len(multiProof.Proof), len(multiProof.Flags))
+
+	leaves := make([]string, len(indices))
+	for i, idx := range indices {
+		leaves[i] = tree.Leaves[idx].Hash
+	}
+
+	valid := VerifyMultiProof(tree.GetRootHash(), indices, leaves, multiProof)
+	fmt.Printf("Multiproof valid: %v\n", valid)
+
+	fmt.Println("\n--- Tampering Detection ---")
+	tamperedLeaves := append([]string{}, leaves...)
+	tamperedLeaves[0] = tree.Hash("corrupted data")
+	tamperedValid := VerifyMultiProof(tree.GetRootHash(), indices, tamperedLeaves, multiProof)
+	fmt.Printf("Multiproof with tampered leaf valid: %v\n", tamperedValid)
+}
+
+// Example: OpenZeppelin-compatible standard Merkle tree over an allowlist
+func exampleStandardMerkleTree() {
+	types := []string{"address", "uint256"}
+	values := [][]string{
+		{"0x1111111111111111111111111111111111111111", "100"},
+		{"0x2222222222222222222222222222222222222222", "200"},
+		{"0x3333333333333333333333333333333333333333", "300"},
+	}
+
+	tree, err := NewStandardMerkleTree(types, values)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Root: 0x%s\n", tree.GetRoot())
+
+	proof, err := tree.GetProof(1)
+	if err != nil {
+		fmt.Printf("Error generating proof: %v\n", err)
+		return
+	}
+	leafHash, _ := standardLeafHash(types, values[1])
+	valid := VerifyStandardProof(tree.GetRoot(), leafHash, proof)
+	fmt.Printf("Proof for leaf [1] (%v): %d siblings, valid=%v\n", values[1], len(proof), valid)
+
+	dumpPath := "standard_tree.json"
+	if err := tree.DumpJSON(dumpPath); err != nil {
+		fmt.Printf("Error dumping tree: %v\n", err)
+		return
+	}
+	fmt.Printf("Dumped tree and leaf values to %s\n", dumpPath)
+}
+
+// Example: building a Merkle tree directly from Arrow/Parquet columns
+func exampleColumnarMerkleTree() {
+	alloc := memory.NewGoAllocator()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "key", Type: arrow.BinaryTypes.String},
+		{Name: "value", Type: arrow.BinaryTypes.String},
+	}, nil)
+
+	builder := array.NewRecordBuilder(alloc, schema)
+	defer builder.Release()
+
+	keyBuilder := builder.Field(0).(*array.StringBuilder)
+	valueBuilder := builder.Field(1).(*array.StringBuilder)
+	for i := 0; i < 10; i++ {
+		keyBuilder.Append(fmt.Sprintf("row_%d", i))
+		valueBuilder.Append(fmt.Sprintf("value_%d", i))
+	}
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	tree, err := NewMerkleTreeFromRecord(record, "key", "value", "sha256")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Built tree from %d Arrow rows, root: %s\n", len(tree.Leaves), tree.GetRootHash())
+
+	sidecarPath := "merkle_leaf_hashes.parquet"
+	if err := WriteLeafHashSidecar(tree, sidecarPath); err != nil {
+		fmt.Printf("Error writing sidecar: %v\n", err)
+		return
+	}
+
+	reproducedRoot, err := ReadLeafHashSidecar(sidecarPath, "sha256")
+	if err != nil {
+		fmt.Printf("Error reading sidecar: %v\n", err)
+		return
+	}
+	fmt.Printf("Reproduced root from sidecar: %s (matches=%v)\n", reproducedRoot, reproducedRoot == tree.GetRootHash())
+}
+
 func demoLSMDatabase() error {
 	if err := demoBasicDatabaseOperations(); err != nil {
 		return err
@@ -66,6 +266,21 @@ func demoLSMDatabase() error {
 	if err := demoWALRecovery(); err != nil {
 		return err
 	}
+	if err := demoWriteBatch(); err != nil {
+		return err
+	}
+	if err := demoConcurrentWrites(); err != nil {
+		return err
+	}
+	if err := demoLSMIterator(); err != nil {
+		return err
+	}
+	if err := demoPrefixRangeScan(); err != nil {
+		return err
+	}
+	if err := demoSnapshot(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -73,6 +288,24 @@ func main() {
 	fmt.Println("========== Merkle Tree Example ==========")
 	exampleMerkleTree()
 
+	fmt.Println("\n========== Sparse Merkle Tree Example ==========")
+	exampleSparseMerkleTree()
+
+	fmt.Println("\n========== Persistent Merkle Tree Example ==========")
+	examplePersistentMerkleTree()
+
+	fmt.Println("\n========== Pluggable Hasher Example ==========")
+	examplePluggableHashers()
+
+	fmt.Println("\n========== Merkle Multiproof Example ==========")
+	exampleMultiProof()
+
+	fmt.Println("\n========== Standard (EVM-Compatible) Merkle Tree Example ==========")
+	exampleStandardMerkleTree()
+
+	fmt.Println("\n========== Columnar (Arrow/Parquet) Merkle Tree Example ==========")
+	exampleColumnarMerkleTree()
+
 	// Create a memory allocator
 	alloc := memory.NewGoAllocator()
 
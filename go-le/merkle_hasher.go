@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Domain-separation prefixes distinguish a leaf hash from an internal
+// node hash so an attacker cannot present an internal node as if it were
+// a leaf (a classic second-preimage attack on unprefixed Merkle trees).
+const (
+	leafDomainPrefix byte = 0x00
+	nodeDomainPrefix byte = 0x01
+)
+
+// Hasher abstracts the hash function a MerkleTree uses for leaves and
+// internal nodes. Implementations must apply distinct domain-separation
+// prefixes to leaves and nodes.
+type Hasher interface {
+	HashLeaf(data []byte) []byte
+	HashNode(left, right []byte) []byte
+	Empty() []byte
+	Size() int
+}
+
+// NewHasher resolves a Hasher by name, matching the string-configured
+// style used elsewhere in this module (e.g. LSMTreeConfig.MemtableType).
+func NewHasher(name string) (Hasher, error) {
+	switch name {
+	case "sha256", "":
+		return SHA256Hasher{}, nil
+	case "sha3-256":
+		return SHA3Hasher{}, nil
+	case "keccak256":
+		return Keccak256Hasher{}, nil
+	case "blake2b":
+		return Blake2bHasher{}, nil
+	case "poseidon":
+		return PoseidonHasher{}, nil
+	default:
+		return nil, fmt.Errorf("unknown hasher %q", name)
+	}
+}
+
+// SHA256Hasher is the default Hasher, matching the tree's original
+// behavior but with domain-separated leaf/node hashing.
+type SHA256Hasher struct{}
+
+func (SHA256Hasher) HashLeaf(data []byte) []byte {
+	h := sha256.Sum256(append([]byte{leafDomainPrefix}, data...))
+	return h[:]
+}
+
+func (SHA256Hasher) HashNode(left, right []byte) []byte {
+	h := sha256.Sum256(append([]byte{nodeDomainPrefix}, append(append([]byte{}, left...), right...)...))
+	return h[:]
+}
+
+func (SHA256Hasher) Empty() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+func (SHA256Hasher) Size() int { return sha256.Size }
+
+// SHA3Hasher uses SHA3-256 (Keccak's NIST standardization), distinct from
+// the raw Keccak-256 used by Ethereum below.
+type SHA3Hasher struct{}
+
+func (SHA3Hasher) HashLeaf(data []byte) []byte {
+	h := sha3.Sum256(append([]byte{leafDomainPrefix}, data...))
+	return h[:]
+}
+
+func (SHA3Hasher) HashNode(left, right []byte) []byte {
+	h := sha3.Sum256(append([]byte{nodeDomainPrefix}, append(append([]byte{}, left...), right...)...))
+	return h[:]
+}
+
+func (SHA3Hasher) Empty() []byte {
+	h := sha3.Sum256(nil)
+	return h[:]
+}
+
+func (SHA3Hasher) Size() int { return 32 }
+
+// Keccak256Hasher is the pre-standardization Keccak-256 used by Ethereum
+// and most EVM tooling, as opposed to NIST SHA3-256.
+type Keccak256Hasher struct{}
+
+func (Keccak256Hasher) HashLeaf(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte{leafDomainPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (Keccak256Hasher) HashNode(left, right []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte{nodeDomainPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func (Keccak256Hasher) Empty() []byte {
+	h := sha3.NewLegacyKeccak256()
+	return h.Sum(nil)
+}
+
+func (Keccak256Hasher) Size() int { return 32 }
+
+// Blake2bHasher uses BLAKE2b-256, a common choice in high-throughput
+// authenticated data structures.
+type Blake2bHasher struct{}
+
+func (Blake2bHasher) HashLeaf(data []byte) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write([]byte{leafDomainPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (Blake2bHasher) HashNode(left, right []byte) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write([]byte{nodeDomainPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func (Blake2bHasher) Empty() []byte {
+	h, _ := blake2b.New256(nil)
+	return h.Sum(nil)
+}
+
+func (Blake2bHasher) Size() int { return 32 }
+
+// PoseidonHasher is a simplified, educational Poseidon-style sponge over
+// a small prime field, included so zk-circuit-friendly trees (where
+// proofs are verified inside a SNARK) can use an arithmetic-friendly
+// hash instead of a bit-oriented one. It is not a production
+// implementation of the Poseidon spec (fixed round constants and MDS
+// matrix from a trusted parameter set would be required for that).
+type PoseidonHasher struct{}
+
+// poseidonModulus is a small prime used only to keep this demo hasher's
+// arithmetic simple; a real deployment would use the scalar field of the
+// target curve (e.g. BN254 or BLS12-381).
+const poseidonModulus uint64 = 2147483647 // 2^31 - 1 (Mersenne prime)
+
+func poseidonPermute(state []uint64) []uint64 {
+	const rounds = 8
+	for r := 0; r < rounds; r++ {
+		for i := range state {
+			state[i] = (state[i] + uint64(r+1)) % poseidonModulus
+			// x^5 S-box, Poseidon's standard choice for this field size.
+			x := state[i]
+			x2 := (x * x) % poseidonModulus
+			x4 := (x2 * x2) % poseidonModulus
+			state[i] = (x4 * x) % poseidonModulus
+		}
+		// Simplified MDS-like mixing: rotate-and-add across the state.
+		mixed := make([]uint64, len(state))
+		for i := range state {
+			mixed[i] = (state[i] + state[(i+1)%len(state)]) % poseidonModulus
+		}
+		state = mixed
+	}
+	return state
+}
+
+func poseidonHash(prefix byte, parts ...[]byte) []byte {
+	state := []uint64{uint64(prefix), 0, 0}
+	for _, part := range parts {
+		for _, b := range part {
+			state[1] = (state[1]*256 + uint64(b)) % poseidonModulus
+		}
+		state = poseidonPermute(state)
+	}
+	out := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		out[i] = byte(state[0] >> (8 * (7 - i)))
+	}
+	return out
+}
+
+func (PoseidonHasher) HashLeaf(data []byte) []byte {
+	return poseidonHash(leafDomainPrefix, data)
+}
+
+func (PoseidonHasher) HashNode(left, right []byte) []byte {
+	return poseidonHash(nodeDomainPrefix, left, right)
+}
+
+func (PoseidonHasher) Empty() []byte {
+	return poseidonHash(leafDomainPrefix)
+}
+
+func (PoseidonHasher) Size() int { return 8 }
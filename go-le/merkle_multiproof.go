@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/hex"
+	"sort"
+)
+
+// MultiProof is a compact proof for a subset of a MerkleTree's leaves,
+// using the OpenZeppelin-style encoding: the Proof slice holds only the
+// sibling hashes that cannot be derived from the supplied leaves, and
+// Flags holds one entry for each internal node, reconstructed bottom up,
+// that has at least one known input: true when both inputs are already
+// known, false when the other input is the next entry in Proof.
+type MultiProof struct {
+	NumLeaves  int
+	Proof      []string
+	Flags      []bool
+	HasherName string
+}
+
+// GetMultiProof builds a MultiProof covering the leaves at indices.
+func (mt *MerkleTree) GetMultiProof(indices []int) MultiProof {
+	targets := make(map[int]bool, len(indices))
+	for _, idx := range indices {
+		targets[idx] = true
+	}
+
+	currentKnown := make([]bool, len(mt.Leaves))
+	currentHash := make([]string, len(mt.Leaves))
+	for i, leaf := range mt.Leaves {
+		currentHash[i] = leaf.Hash
+		currentKnown[i] = targets[i]
+	}
+
+	var proof []string
+	var flags []bool
+
+	for len(currentHash) > 1 {
+		nextKnown := make([]bool, 0, (len(currentHash)+1)/2)
+		nextHash := make([]string, 0, (len(currentHash)+1)/2)
+
+		for i := 0; i < len(currentHash); i += 2 {
+			leftKnown, leftHash := currentKnown[i], currentHash[i]
+			rightKnown, rightHash := leftKnown, leftHash
+			if i+1 < len(currentHash) {
+				rightKnown, rightHash = currentKnown[i+1], currentHash[i+1]
+			}
+
+			parentHash := mt.hashNode(leftHash, rightHash)
+
+			var known bool
+			switch {
+			case leftKnown && rightKnown:
+				flags = append(flags, true)
+				known = true
+			case leftKnown:
+				proof = append(proof, rightHash)
+				flags = append(flags, false)
+				known = true
+			case rightKnown:
+				proof = append(proof, leftHash)
+				flags = append(flags, false)
+				known = true
+			}
+
+			nextKnown = append(nextKnown, known)
+			nextHash = append(nextHash, parentHash)
+		}
+
+		currentKnown, currentHash = nextKnown, nextHash
+	}
+
+	return MultiProof{
+		NumLeaves:  len(mt.Leaves),
+		Proof:      proof,
+		Flags:      flags,
+		HasherName: mt.HasherName,
+	}
+}
+
+// VerifyMultiProof checks that leaves (at the given indices) are members
+// of the tree with the given root, using proof's compact sibling set and
+// flag stream rather than one independent proof per leaf.
+func VerifyMultiProof(root string, indices []int, leaves []string, proof MultiProof) bool {
+	if len(indices) != len(leaves) {
+		return false
+	}
+
+	hasher, err := NewHasher(proof.HasherName)
+	if err != nil {
+		return false
+	}
+
+	type indexedLeaf struct {
+		index int
+		hash  string
+	}
+	sorted := make([]indexedLeaf, len(indices))
+	for i, idx := range indices {
+		sorted[i] = indexedLeaf{idx, leaves[i]}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].index < sorted[j].index })
+
+	currentKnown := make([]bool, proof.NumLeaves)
+	currentHash := make([]string, proof.NumLeaves)
+	pos := 0
+	for i := 0; i < proof.NumLeaves; i++ {
+		if pos < len(sorted) && sorted[pos].index == i {
+			currentKnown[i] = true
+			currentHash[i] = sorted[pos].hash
+			pos++
+		}
+	}
+	if pos != len(sorted) {
+		return false // an index fell outside [0, NumLeaves) or was repeated
+	}
+
+	proofPos, flagPos := 0, 0
+	for len(currentHash) > 1 {
+		nextKnown := make([]bool, 0, (len(currentHash)+1)/2)
+		nextHash := make([]string, 0, (len(currentHash)+1)/2)
+
+		for i := 0; i < len(currentHash); i += 2 {
+			leftKnown, leftHash := currentKnown[i], currentHash[i]
+			rightKnown, rightHash := leftKnown, leftHash
+			if i+1 < len(currentHash) {
+				rightKnown, rightHash = currentKnown[i+1], currentHash[i+1]
+			}
+
+			var known bool
+			switch {
+			case leftKnown && rightKnown:
+				if flagPos >= len(proof.Flags) || !proof.Flags[flagPos] {
+					return false
+				}
+				flagPos++
+				known = true
+			case leftKnown:
+				if flagPos >= len(proof.Flags) || proof.Flags[flagPos] || proofPos >= len(proof.Proof) {
+					return false
+				}
+				rightHash = proof.Proof[proofPos]
+				proofPos++
+				flagPos++
+				known = true
+			case rightKnown:
+				if flagPos >= len(proof.Flags) || proof.Flags[flagPos] || proofPos >= len(proof.Proof) {
+					return false
+				}
+				leftHash = proof.Proof[proofPos]
+				proofPos++
+				flagPos++
+				known = true
+			}
+
+			nextKnown = append(nextKnown, known)
+			nextHash = append(nextHash, hashPairWith(hasher, leftHash, rightHash))
+		}
+
+		currentKnown, currentHash = nextKnown, nextHash
+	}
+
+	if len(currentHash) != 1 || !currentKnown[0] {
+		return false
+	}
+	return currentHash[0] == root
+}
+
+// hashPairWith hex-decodes leftHex/rightHex and returns the hex-encoded,
+// domain-separated node hash of the pair, mirroring MerkleTree.hashNode
+// for verifiers that only have a Hasher, not a tree.
+func hashPairWith(hasher Hasher, leftHex, rightHex string) string {
+	left, err := hex.DecodeString(leftHex)
+	if err != nil {
+		return ""
+	}
+	right, err := hex.DecodeString(rightHex)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hasher.HashNode(left, right))
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// merkleHashWorkers bounds how many goroutines hash leaves concurrently
+// when building a tree from a columnar source.
+const merkleHashWorkers = 4
+
+// NewMerkleTreeFromRecord builds a MerkleTree by hashing keyCol/valueCol
+// directly out of an in-memory arrow.Record, without ever materializing
+// the rows as a `[]string` of data blocks: each row's leaf hash is
+// `Hash(key||value)`, computed by a small pool of worker goroutines.
+func NewMerkleTreeFromRecord(rec arrow.Record, keyCol, valueCol string, hasherName string) (*MerkleTree, error) {
+	keyArr, valueArr, err := recordStringColumns(rec, keyCol, valueCol)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := NewHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+
+	leafHashes := hashRowsParallel(hasher, keyArr, valueArr)
+	return buildMerkleTreeFromLeafHashes(hasher, hasherName, leafHashes)
+}
+
+// NewMerkleTreeFromParquet streams rows out of the Parquet file at path
+// one row-group batch at a time, hashing each batch's rows in parallel
+// and folding the resulting leaf hashes into a tree, so the caller never
+// needs to hold the full dataset as a `[]string` of data blocks.
+func NewMerkleTreeFromParquet(path, keyCol, valueCol, hasherName string) (*MerkleTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := NewHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+
+	recordReader, err := arrowReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer recordReader.Release()
+
+	var leafHashes []string
+	for recordReader.Next() {
+		batch := recordReader.Record()
+		keyArr, valueArr, err := recordStringColumns(batch, keyCol, valueCol)
+		if err != nil {
+			return nil, err
+		}
+		leafHashes = append(leafHashes, hashRowsParallel(hasher, keyArr, valueArr)...)
+	}
+	if err := recordReader.Err(); err != nil {
+		return nil, err
+	}
+
+	return buildMerkleTreeFromLeafHashes(hasher, hasherName, leafHashes)
+}
+
+// recordStringColumns extracts keyCol/valueCol from rec as string arrays.
+func recordStringColumns(rec arrow.Record, keyCol, valueCol string) (*array.String, *array.String, error) {
+	schema := rec.Schema()
+	keyIdx := schema.FieldIndices(keyCol)
+	valueIdx := schema.FieldIndices(valueCol)
+	if len(keyIdx) == 0 || len(valueIdx) == 0 {
+		return nil, nil, fmt.Errorf("columns %q/%q not found in record schema", keyCol, valueCol)
+	}
+
+	keyArr, ok := rec.Column(keyIdx[0]).(*array.String)
+	if !ok {
+		return nil, nil, fmt.Errorf("column %q is not a string array", keyCol)
+	}
+	valueArr, ok := rec.Column(valueIdx[0]).(*array.String)
+	if !ok {
+		return nil, nil, fmt.Errorf("column %q is not a string array", valueCol)
+	}
+	return keyArr, valueArr, nil
+}
+
+// hashRowsParallel computes one leaf hash per row, splitting the batch
+// into merkleHashWorkers chunks hashed concurrently.
+func hashRowsParallel(hasher Hasher, keyArr, valueArr *array.String) []string {
+	n := keyArr.Len()
+	leafHashes := make([]string, n)
+	if n == 0 {
+		return leafHashes
+	}
+
+	workers := merkleHashWorkers
+	if n < workers {
+		workers = n
+	}
+	chunkSize := (n + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start := w * chunkSize
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		if start >= end {
+			continue
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				combined := keyArr.Value(i) + valueArr.Value(i)
+				leafHashes[i] = hex.EncodeToString(hasher.HashLeaf([]byte(combined)))
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return leafHashes
+}
+
+// buildMerkleTreeFromLeafHashes folds precomputed, hex-encoded leaf
+// hashes bottom-up into a MerkleTree without re-hashing the original row
+// data (which may no longer even be resident in memory).
+func buildMerkleTreeFromLeafHashes(hasher Hasher, hasherName string, leafHashes []string) (*MerkleTree, error) {
+	if len(leafHashes) == 0 {
+		return nil, fmt.Errorf("no rows to build a Merkle tree from")
+	}
+
+	tree := &MerkleTree{Hasher: hasher, HasherName: hasherName}
+	tree.Leaves = make([]*MerkleNode, len(leafHashes))
+	currentLevel := make([]*MerkleNode, len(leafHashes))
+	for i, h := range leafHashes {
+		leaf := &MerkleNode{Hash: h, IsLeaf: true}
+		tree.Leaves[i] = leaf
+		currentLevel[i] = leaf
+	}
+
+	for len(currentLevel) > 1 {
+		var nextLevel []*MerkleNode
+		for i := 0; i < len(currentLevel); i += 2 {
+			left := currentLevel[i]
+			right := left
+			if i+1 < len(currentLevel) {
+				right = currentLevel[i+1]
+			}
+			parent := &MerkleNode{
+				Hash:  tree.hashNode(left.Hash, right.Hash),
+				Left:  left,
+				Right: right,
+			}
+			nextLevel = append(nextLevel, parent)
+		}
+		currentLevel = nextLevel
+	}
+	tree.Root = currentLevel[0]
+
+	return tree, nil
+}
+
+// WriteLeafHashSidecar persists a tree's leaf hashes as a single-column
+// Parquet file, so a later verifier can load just the hash column and
+// refold the root without re-reading the original dataset.
+func WriteLeafHashSidecar(tree *MerkleTree, path string) error {
+	alloc := memory.NewGoAllocator()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "leaf_hash", Type: arrow.BinaryTypes.String},
+	}, nil)
+
+	builder := array.NewRecordBuilder(alloc, schema)
+	defer builder.Release()
+
+	hashBuilder := builder.Field(0).(*array.StringBuilder)
+	for _, leaf := range tree.Leaves {
+		hashBuilder.Append(leaf.Hash)
+	}
+
+	record := builder.NewRecord()
+	defer record.Release()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writer, err := pqarrow.NewFileWriter(schema, f, parquet.NewWriterProperties(), pqarrow.NewArrowWriterProperties())
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return writer.Write(record)
+}
+
+// ReadLeafHashSidecar loads just the leaf_hash column written by
+// WriteLeafHashSidecar and reproduces the tree's root hash, using
+// hasherName to recombine pairs the same way the original tree did.
+func ReadLeafHashSidecar(path, hasherName string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	reader, err := file.NewParquetReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return "", err
+	}
+
+	table, err := arrowReader.ReadTable(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer table.Release()
+
+	hashCol := table.Column(0).Data()
+	var leafHashes []string
+	for c := 0; c < hashCol.Len(); c++ {
+		chunk := hashCol.Chunk(c).(*array.String)
+		for i := 0; i < chunk.Len(); i++ {
+			leafHashes = append(leafHashes, chunk.Value(i))
+		}
+	}
+
+	hasher, err := NewHasher(hasherName)
+	if err != nil {
+		return "", err
+	}
+
+	tree, err := buildMerkleTreeFromLeafHashes(hasher, hasherName, leafHashes)
+	if err != nil {
+		return "", err
+	}
+	return tree.GetRootHash(), nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// StandardLeaf is one row of a StandardMerkleTree: a tuple of ABI-typed
+// values, e.g. ("address", "0x...") + ("uint256", "100").
+type StandardLeaf struct {
+	Types  []string
+	Values []string
+}
+
+// StandardMerkleTree mirrors OpenZeppelin's standard tree: leaves are
+// double-keccak256 hashes of ABI-encoded tuples, and internal nodes sort
+// their two children before concatenating so proofs are
+// position-independent (a proof doesn't need to say "left" or "right").
+// Unlike the JS library, leaves keep their input order (they are not
+// sorted by hash) and an odd node at the end of a level is paired with
+// itself, so roots are not interchangeable with that library's.
+type StandardMerkleTree struct {
+	Leaves []StandardLeaf
+	// tree holds every level from leaves (index 0) to the root (last
+	// level, one hash); DumpJSON flattens it in that order.
+	tree [][]string
+}
+
+// NewStandardMerkleTree builds a StandardMerkleTree from tuples described
+// by a shared ABI type list and one value list per leaf.
+func NewStandardMerkleTree(types []string, values [][]string) (*StandardMerkleTree, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("values cannot be empty")
+	}
+
+	leaves := make([]StandardLeaf, len(values))
+	level := make([]string, len(values))
+	for i, v := range values {
+		if len(v) != len(types) {
+			return nil, fmt.Errorf("leaf %d has %d values, expected %d", i, len(v), len(types))
+		}
+		leaves[i] = StandardLeaf{Types: types, Values: v}
+		hash, err := standardLeafHash(types, v)
+		if err != nil {
+			return nil, fmt.Errorf("leaf %d: %w", i, err)
+		}
+		level[i] = hash
+	}
+
+	smt := &StandardMerkleTree{Leaves: leaves, tree: [][]string{level}}
+	for len(level) > 1 {
+		var next []string
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := left
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			next = append(next, standardNodeHash(left, right))
+		}
+		level = next
+		smt.tree = append(smt.tree, level)
+	}
+
+	return smt, nil
+}
+
+// standardLeafHash computes keccak256(keccak256(abi.encode(types, values))),
+// double-hashed so a leaf's preimage can never collide with an internal
+// node's shorter (64-byte) preimage.
+func standardLeafHash(types []string, values []string) (string, error) {
+	encoded, err := abiEncode(types, values)
+	if err != nil {
+		return "", err
+	}
+	inner := keccak256(encoded)
+	outer := keccak256(inner)
+	return hex.EncodeToString(outer), nil
+}
+
+// standardNodeHash sorts the two child hashes lexicographically before
+// concatenating, matching OpenZeppelin's MerkleProof.sol `_hashPair`, so
+// that proofs don't need to record which side a sibling was on.
+func standardNodeHash(a, b string) string {
+	aBytes, _ := hex.DecodeString(a)
+	bBytes, _ := hex.DecodeString(b)
+	if bytes.Compare(bBytes, aBytes) < 0 {
+		aBytes, bBytes = bBytes, aBytes
+	}
+	combined := append(append([]byte{}, aBytes...), bBytes...)
+	return hex.EncodeToString(keccak256(combined))
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// GetRoot returns the tree's root hash.
+func (smt *StandardMerkleTree) GetRoot() string {
+	top := smt.tree[len(smt.tree)-1]
+	return top[0]
+}
+
+// GetProof returns the flat sibling-hash proof for the leaf at leafIndex,
+// in the format consumed by OpenZeppelin's `MerkleProof.verify`.
+func (smt *StandardMerkleTree) GetProof(leafIndex int) ([]string, error) {
+	if leafIndex < 0 || leafIndex >= len(smt.tree[0]) {
+		return nil, fmt.Errorf("leaf index %d out of range", leafIndex)
+	}
+
+	var proof []string
+	idx := leafIndex
+	for level := 0; level < len(smt.tree)-1; level++ {
+		siblingIdx := idx ^ 1
+		if siblingIdx < len(smt.tree[level]) {
+			proof = append(proof, smt.tree[level][siblingIdx])
+		} else {
+			proof = append(proof, smt.tree[level][idx])
+		}
+		idx /= 2
+	}
+	return proof, nil
+}
+
+// VerifyStandardProof reconstructs the root from leafHash and a flat
+// sibling proof, sorting each pair the same way standardNodeHash does.
+func VerifyStandardProof(root, leafHash string, proof []string) bool {
+	current := leafHash
+	for _, sibling := range proof {
+		current = standardNodeHash(current, sibling)
+	}
+	return current == root
+}
+
+// standardTreeDump uses the field names of the JS @openzeppelin/merkle-tree
+// library's "standard-v1" JSON dump, not its layout: Tree lists the
+// levels bottom-up with the root last, hashes are hex without a 0x
+// prefix, and TreeIndex is the leaf's input position. These dumps are
+// therefore not interchangeable with dumps written by the JS library.
+type standardTreeDump struct {
+	Format       string              `json:"format"`
+	LeafEncoding []string            `json:"leafEncoding"`
+	Tree         []string            `json:"tree"`
+	Values       []standardDumpValue `json:"values"`
+}
+
+type standardDumpValue struct {
+	Value     []string `json:"value"`
+	TreeIndex int      `json:"treeIndex"`
+}
+
+// DumpJSON serializes the tree (flattened bottom level to root) and its
+// leaf values to the standard-v1 JSON format and writes it to path.
+func (smt *StandardMerkleTree) DumpJSON(path string) error {
+	var flatTree []string
+	for _, level := range smt.tree {
+		flatTree = append(flatTree, level...)
+	}
+
+	values := make([]standardDumpValue, len(smt.Leaves))
+	for i, leaf := range smt.Leaves {
+		values[i] = standardDumpValue{Value: leaf.Values, TreeIndex: i}
+	}
+
+	dump := standardTreeDump{
+		Format:       "standard-v1",
+		LeafEncoding: smt.Leaves[0].Types,
+		Tree:         flatTree,
+		Values:       values,
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadStandardMerkleTreeJSON reads a standard-v1 dump as produced by
+// DumpJSON (root last in Tree) and rebuilds the tree from its leaf values,
+// verifying that the recomputed root matches the dumped one.
+func LoadStandardMerkleTreeJSON(path string) (*StandardMerkleTree, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dump standardTreeDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, err
+	}
+	if dump.Format != "standard-v1" {
+		return nil, fmt.Errorf("unsupported tree dump format %q", dump.Format)
+	}
+
+	values := make([][]string, len(dump.Values))
+	sorted := append([]standardDumpValue{}, dump.Values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].TreeIndex < sorted[j].TreeIndex })
+	for i, v := range sorted {
+		values[i] = v.Value
+	}
+
+	smt, err := NewStandardMerkleTree(dump.LeafEncoding, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(dump.Tree) > 0 && smt.GetRoot() != dump.Tree[len(dump.Tree)-1] {
+		return nil, fmt.Errorf("recomputed root does not match dumped root")
+	}
+	return smt, nil
+}
+
+// abiEncode implements a minimal subset of Solidity's ABI tuple encoding
+// (abi.encode) for the types this module supports: fixed-size "address",
+// "uint256", "bytes32" words in the head, and a dynamic "string" whose
+// offset goes in the head with its length-prefixed, 32-byte-padded data
+// appended to the tail.
+func abiEncode(types []string, values []string) ([]byte, error) {
+	head := make([][]byte, len(types))
+	var tail []byte
+	headSize := len(types) * 32
+
+	for i, t := range types {
+		switch t {
+		case "address":
+			word, err := abiEncodeAddress(values[i])
+			if err != nil {
+				return nil, err
+			}
+			head[i] = word
+		case "uint256":
+			word, err := abiEncodeUint256(values[i])
+			if err != nil {
+				return nil, err
+			}
+			head[i] = word
+		case "bytes32":
+			word, err := abiEncodeBytes32(values[i])
+			if err != nil {
+				return nil, err
+			}
+			head[i] = word
+		case "string":
+			offset := headSize + len(tail)
+			head[i] = abiEncodeUint256Int(int64(offset))
+			tail = append(tail, abiEncodeDynamicBytes([]byte(values[i]))...)
+		default:
+			return nil, fmt.Errorf("unsupported ABI type %q", t)
+		}
+	}
+
+	var out []byte
+	for _, word := range head {
+		out = append(out, word...)
+	}
+	out = append(out, tail...)
+	return out, nil
+}
+
+func abiEncodeAddress(value string) ([]byte, error) {
+	value = strings.TrimPrefix(value, "0x")
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", value, err)
+	}
+	if len(raw) != 20 {
+		return nil, fmt.Errorf("address %q must be 20 bytes", value)
+	}
+	word := make([]byte, 32)
+	copy(word[12:], raw)
+	return word, nil
+}
+
+func abiEncodeUint256(value string) ([]byte, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid uint256 %q", value)
+	}
+	return abiEncodeBigInt(n), nil
+}
+
+func abiEncodeUint256Int(value int64) []byte {
+	return abiEncodeBigInt(big.NewInt(value))
+}
+
+func abiEncodeBigInt(n *big.Int) []byte {
+	word := make([]byte, 32)
+	n.FillBytes(word)
+	return word
+}
+
+func abiEncodeBytes32(value string) ([]byte, error) {
+	value = strings.TrimPrefix(value, "0x")
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytes32 %q: %w", value, err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("bytes32 %q must be 32 bytes", value)
+	}
+	return raw, nil
+}
+
+func abiEncodeDynamicBytes(data []byte) []byte {
+	lengthWord := abiEncodeUint256Int(int64(len(data)))
+	padded := append([]byte{}, data...)
+	if rem := len(padded) % 32; rem != 0 {
+		padded = append(padded, make([]byte, 32-rem)...)
+	}
+	return append(lengthWord, padded...)
+}
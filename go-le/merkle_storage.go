@@ -0,0 +1,518 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// storedNode is the serialized form of a MerkleNode kept by a Storage
+// backend, addressed by its own hash.
+type storedNode struct {
+	Hash      string `json:"hash"`
+	Data      string `json:"data"`
+	LeftHash  string `json:"left_hash,omitempty"`
+	RightHash string `json:"right_hash,omitempty"`
+	IsLeaf    bool   `json:"is_leaf"`
+}
+
+// Storage is a pluggable backend for a persistent MerkleTree: nodes are
+// addressed by hash, and the current root hash is tracked separately so a
+// tree can be rebuilt by walking from GetRoot() through Get().
+type Storage interface {
+	Get(hash string) (*storedNode, error)
+	Put(hash string, node *storedNode) error
+	SetRoot(hash string) error
+	GetRoot() (string, error)
+
+	// NewTx starts a transaction; all Get/Put/SetRoot calls against the
+	// returned Tx are only visible to other readers once Commit is called.
+	NewTx() (StorageTx, error)
+}
+
+// StorageTx groups a set of node writes and a single root update so a
+// crash mid-insert cannot leave a dangling root: callers must call
+// SetRoot last, right before Commit.
+type StorageTx interface {
+	Get(hash string) (*storedNode, error)
+	Put(hash string, node *storedNode) error
+	SetRoot(hash string) error
+	Commit() error
+	Rollback() error
+}
+
+// ErrNodeNotFound is returned by Get when no node is stored under the
+// given hash.
+var ErrNodeNotFound = fmt.Errorf("merkle storage: node not found")
+
+// MemStorage is an in-memory Storage implementation backed by a map. It
+// is the default backend for persistent trees used only within a single
+// process.
+type MemStorage struct {
+	mutex sync.RWMutex
+	nodes map[string]*storedNode
+	root  string
+}
+
+// NewMemStorage creates an empty in-memory storage backend.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{nodes: make(map[string]*storedNode)}
+}
+
+// Get retrieves the node stored under hash.
+func (ms *MemStorage) Get(hash string) (*storedNode, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	node, ok := ms.nodes[hash]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	return node, nil
+}
+
+// Put stores node under hash.
+func (ms *MemStorage) Put(hash string, node *storedNode) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.nodes[hash] = node
+	return nil
+}
+
+// SetRoot records the current root hash.
+func (ms *MemStorage) SetRoot(hash string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.root = hash
+	return nil
+}
+
+// GetRoot returns the current root hash, or "" if none has been set.
+func (ms *MemStorage) GetRoot() (string, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	return ms.root, nil
+}
+
+// NewTx starts a transaction. Writes made through the returned Tx are
+// staged locally and only applied to the backing map on Commit.
+func (ms *MemStorage) NewTx() (StorageTx, error) {
+	return &memStorageTx{storage: ms, staged: make(map[string]*storedNode)}, nil
+}
+
+// memStorageTx stages writes against a MemStorage until Commit.
+type memStorageTx struct {
+	storage  *MemStorage
+	staged   map[string]*storedNode
+	rootSet  bool
+	newRoot  string
+	finished bool
+}
+
+func (tx *memStorageTx) Get(hash string) (*storedNode, error) {
+	if node, ok := tx.staged[hash]; ok {
+		return node, nil
+	}
+	return tx.storage.Get(hash)
+}
+
+func (tx *memStorageTx) Put(hash string, node *storedNode) error {
+	tx.staged[hash] = node
+	return nil
+}
+
+func (tx *memStorageTx) SetRoot(hash string) error {
+	tx.rootSet = true
+	tx.newRoot = hash
+	return nil
+}
+
+func (tx *memStorageTx) Commit() error {
+	if tx.finished {
+		return fmt.Errorf("merkle storage: transaction already finished")
+	}
+	tx.finished = true
+
+	tx.storage.mutex.Lock()
+	defer tx.storage.mutex.Unlock()
+
+	for hash, node := range tx.staged {
+		tx.storage.nodes[hash] = node
+	}
+	// The root is committed last so a crash between node writes and the
+	// root update leaves the previous, still-valid root in place.
+	if tx.rootSet {
+		tx.storage.root = tx.newRoot
+	}
+	return nil
+}
+
+func (tx *memStorageTx) Rollback() error {
+	tx.finished = true
+	tx.staged = nil
+	return nil
+}
+
+// boltNodesBucket and boltMetaBucket hold tree nodes and the current root
+// hash respectively.
+var (
+	boltNodesBucket = []byte("merkle_nodes")
+	boltMetaBucket  = []byte("merkle_meta")
+	boltRootKey     = []byte("root")
+)
+
+// BoltStorage is a disk-backed Storage implementation using BoltDB, so a
+// Merkle tree can outgrow RAM and survive process restarts.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// NewBoltStorage opens (creating if necessary) a BoltDB file at path as a
+// Merkle tree storage backend.
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt storage: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltNodesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing bolt storage: %w", err)
+	}
+
+	return &BoltStorage{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (bs *BoltStorage) Close() error {
+	return bs.db.Close()
+}
+
+// Get retrieves the node stored under hash.
+func (bs *BoltStorage) Get(hash string) (*storedNode, error) {
+	var node *storedNode
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltNodesBucket).Get([]byte(hash))
+		if raw == nil {
+			return ErrNodeNotFound
+		}
+		node = &storedNode{}
+		return json.Unmarshal(raw, node)
+	})
+	return node, err
+}
+
+// Put stores node under hash in its own transaction.
+func (bs *BoltStorage) Put(hash string, node *storedNode) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNodesBucket).Put([]byte(hash), data)
+	})
+}
+
+// SetRoot records the current root hash.
+func (bs *BoltStorage) SetRoot(hash string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltMetaBucket).Put(boltRootKey, []byte(hash))
+	})
+}
+
+// GetRoot returns the current root hash, or "" if none has been set.
+func (bs *BoltStorage) GetRoot() (string, error) {
+	var root string
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(boltMetaBucket).Get(boltRootKey)
+		root = string(raw)
+		return nil
+	})
+	return root, err
+}
+
+// NewTx starts a BoltDB read-write transaction; all node writes and the
+// final SetRoot happen atomically on Commit, matching Bolt's own ACID
+// transaction semantics.
+func (bs *BoltStorage) NewTx() (StorageTx, error) {
+	tx, err := bs.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	return &boltStorageTx{tx: tx}, nil
+}
+
+type boltStorageTx struct {
+	tx *bolt.Tx
+}
+
+func (btx *boltStorageTx) Get(hash string) (*storedNode, error) {
+	raw := btx.tx.Bucket(boltNodesBucket).Get([]byte(hash))
+	if raw == nil {
+		return nil, ErrNodeNotFound
+	}
+	node := &storedNode{}
+	if err := json.Unmarshal(raw, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (btx *boltStorageTx) Put(hash string, node *storedNode) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return btx.tx.Bucket(boltNodesBucket).Put([]byte(hash), data)
+}
+
+func (btx *boltStorageTx) SetRoot(hash string) error {
+	// Written into the same Bolt transaction as the node writes, so the
+	// new root becomes visible only when Commit applies them all at once.
+	return btx.tx.Bucket(boltMetaBucket).Put(boltRootKey, []byte(hash))
+}
+
+func (btx *boltStorageTx) Commit() error {
+	return btx.tx.Commit()
+}
+
+func (btx *boltStorageTx) Rollback() error {
+	return btx.tx.Rollback()
+}
+
+// PersistentMerkleTree is a MerkleTree variant whose nodes live in a
+// Storage backend instead of only as in-memory pointers, so it can be
+// built over datasets larger than RAM and reopened across restarts.
+type PersistentMerkleTree struct {
+	Storage    Storage
+	Hasher     Hasher
+	HasherName string
+	numLeaves  int
+}
+
+// NewPersistentMerkleTree creates a tree over the given storage backend,
+// resolving hasherName the same way NewMerkleTree does. If hasherName is
+// unknown, it falls back to SHA-256 rather than failing the session.
+func NewPersistentMerkleTree(storage Storage, hasherName string) *PersistentMerkleTree {
+	hasher, err := NewHasher(hasherName)
+	if err != nil {
+		hasher = SHA256Hasher{}
+		hasherName = "sha256"
+	}
+	return &PersistentMerkleTree{Storage: storage, Hasher: hasher, HasherName: hasherName}
+}
+
+// hashLeaf hex-encodes the domain-separated leaf hash of data.
+func (pmt *PersistentMerkleTree) hashLeaf(data string) string {
+	return hex.EncodeToString(pmt.Hasher.HashLeaf([]byte(data)))
+}
+
+// hashNode hex-decodes leftHex/rightHex and returns the hex-encoded,
+// domain-separated node hash of the pair.
+func (pmt *PersistentMerkleTree) hashNode(leftHex, rightHex string) string {
+	left, _ := hex.DecodeString(leftHex)
+	right, _ := hex.DecodeString(rightHex)
+	return hex.EncodeToString(pmt.Hasher.HashNode(left, right))
+}
+
+// Build constructs the tree from dataBlocks, writing every node through a
+// single transaction and committing the new root hash last.
+func (pmt *PersistentMerkleTree) Build(dataBlocks []string) error {
+	tx, err := pmt.Storage.NewTx()
+	if err != nil {
+		return err
+	}
+
+	rootHash, err := pmt.buildLevel(tx, dataBlocks)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.SetRoot(rootHash); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	pmt.numLeaves = len(dataBlocks)
+	return tx.Commit()
+}
+
+// buildLevel writes leaf nodes for dataBlocks and folds them bottom-up,
+// returning the resulting root hash.
+func (pmt *PersistentMerkleTree) buildLevel(tx StorageTx, dataBlocks []string) (string, error) {
+	if len(dataBlocks) == 0 {
+		return "", fmt.Errorf("data blocks cannot be empty")
+	}
+
+	level := make([]string, len(dataBlocks))
+	for i, data := range dataBlocks {
+		h := pmt.hashLeaf(data)
+		if err := tx.Put(h, &storedNode{Hash: h, Data: data, IsLeaf: true}); err != nil {
+			return "", err
+		}
+		level[i] = h
+	}
+
+	for len(level) > 1 {
+		var next []string
+		for i := 0; i < len(level); i += 2 {
+			left := level[i]
+			right := left
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			parentHash := pmt.hashNode(left, right)
+			node := &storedNode{Hash: parentHash, LeftHash: left, RightHash: right}
+			if err := tx.Put(parentHash, node); err != nil {
+				return "", err
+			}
+			next = append(next, parentHash)
+		}
+		level = next
+	}
+
+	return level[0], nil
+}
+
+// Add appends a new data block and rebuilds the tree, via a single
+// transaction, over the existing leaves plus the new one. Real SMT-style
+// incremental updates avoid the full rebuild; this module keeps the
+// simpler whole-tree rebuild used by the in-memory MerkleTree.
+func (pmt *PersistentMerkleTree) Add(data string) error {
+	leaves, err := pmt.leafData()
+	if err != nil {
+		return err
+	}
+	leaves = append(leaves, data)
+	return pmt.Build(leaves)
+}
+
+// leafData walks the tree from its root and collects leaf data in order.
+func (pmt *PersistentMerkleTree) leafData() ([]string, error) {
+	rootHash, err := pmt.Storage.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	if rootHash == "" {
+		return nil, nil
+	}
+
+	var leaves []string
+	var walk func(hash string) error
+	walk = func(hash string) error {
+		node, err := pmt.Storage.Get(hash)
+		if err != nil {
+			return err
+		}
+		if node.IsLeaf {
+			leaves = append(leaves, node.Data)
+			return nil
+		}
+		if err := walk(node.LeftHash); err != nil {
+			return err
+		}
+		if node.RightHash != node.LeftHash {
+			return walk(node.RightHash)
+		}
+		return nil
+	}
+
+	if err := walk(rootHash); err != nil {
+		return nil, err
+	}
+	return leaves, nil
+}
+
+// GetRootHash returns the committed root hash.
+func (pmt *PersistentMerkleTree) GetRootHash() (string, error) {
+	return pmt.Storage.GetRoot()
+}
+
+// GetProof generates a Merkle proof for the leaf at leafIndex by walking
+// storage from the root rather than in-memory pointers.
+func (pmt *PersistentMerkleTree) GetProof(leafIndex int) ([]MerkleProof, error) {
+	rootHash, err := pmt.Storage.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	if rootHash == "" {
+		return nil, fmt.Errorf("merkle storage: tree is empty")
+	}
+
+	// Each node covers a power-of-two range of leaf indices, halved at
+	// every level. The leaf count comes from the last Build, or from the
+	// stored leaves when the tree was reopened over existing storage.
+	numLeaves := pmt.numLeaves
+	if numLeaves == 0 {
+		leaves, err := pmt.leafData()
+		if err != nil {
+			return nil, err
+		}
+		numLeaves = len(leaves)
+	}
+	width := 1
+	for width < numLeaves {
+		width *= 2
+	}
+	var proof []MerkleProof
+	var descend func(hash string, lo, hi int) (bool, error)
+	descend = func(hash string, lo, hi int) (bool, error) {
+		node, err := pmt.Storage.Get(hash)
+		if err != nil {
+			return false, err
+		}
+		if node.IsLeaf {
+			return lo == leafIndex, nil
+		}
+
+		mid := lo + (hi-lo)/2
+		foundLeft, err := descend(node.LeftHash, lo, mid)
+		if err != nil {
+			return false, err
+		}
+		if foundLeft {
+			if node.RightHash != "" {
+				rightNode, err := pmt.Storage.Get(node.RightHash)
+				if err != nil {
+					return false, err
+				}
+				proof = append(proof, MerkleProof{SiblingHash: rightNode.Hash, Position: "right"})
+			}
+			return true, nil
+		}
+
+		foundRight, err := descend(node.RightHash, mid+1, hi)
+		if err != nil {
+			return false, err
+		}
+		if foundRight {
+			leftNode, err := pmt.Storage.Get(node.LeftHash)
+			if err != nil {
+				return false, err
+			}
+			proof = append(proof, MerkleProof{SiblingHash: leftNode.Hash, Position: "left"})
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	if _, err := descend(rootHash, 0, width-1); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -23,32 +24,38 @@ type MerkleProof struct {
 
 // MerkleTree represents a binary Merkle tree
 type MerkleTree struct {
-	Root     *MerkleNode
-	Leaves   []*MerkleNode
-	HashFunc string
+	Root       *MerkleNode
+	Leaves     []*MerkleNode
+	Hasher     Hasher
+	HasherName string
 }
 
-// NewMerkleTree creates a new Merkle tree from data blocks
-func NewMerkleTree(dataBlocks []string, hashFunc string) (*MerkleTree, error) {
+// NewMerkleTree creates a new Merkle tree from data blocks, resolving
+// hasherName (e.g. "sha256", "keccak256") to a Hasher implementation.
+func NewMerkleTree(dataBlocks []string, hasherName string) (*MerkleTree, error) {
 	if len(dataBlocks) == 0 {
 		return nil, fmt.Errorf("data blocks cannot be empty")
 	}
 
+	hasher, err := NewHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+
 	tree := &MerkleTree{
-		HashFunc: hashFunc,
+		Hasher:     hasher,
+		HasherName: hasherName,
 	}
 
 	tree.Build(dataBlocks)
 	return tree, nil
 }
 
-// Hash computes the hash of data
+// Hash computes a node's hex-encoded leaf hash using the tree's Hasher.
+// Retained for callers that still reason about hashes as hex strings
+// (proof display, root comparisons).
 func (mt *MerkleTree) Hash(data string) string {
-	if mt.HashFunc != "sha256" {
-		mt.HashFunc = "sha256"
-	}
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("%x", hash)
+	return hex.EncodeToString(mt.Hasher.HashLeaf([]byte(data)))
 }
 
 // Build constructs the Merkle tree from data blocks
@@ -56,7 +63,7 @@ func (mt *MerkleTree) Build(dataBlocks []string) {
 	// Create leaf nodes
 	mt.Leaves = make([]*MerkleNode, len(dataBlocks))
 	for i, data := range dataBlocks {
-		hash := mt.Hash(data)
+		hash := hex.EncodeToString(mt.Hasher.HashLeaf([]byte(data)))
 		leaf := &MerkleNode{
 			Hash:   hash,
 			Data:   data,
@@ -82,9 +89,9 @@ func (mt *MerkleTree) Build(dataBlocks []string) {
 				right = currentLevel[i+1]
 			}
 
-			// Combine hashes
-			combined := left.Hash + right.Hash
-			parentHash := mt.Hash(combined)
+			// Combine hashes with domain-separated node hashing so an
+			// internal node can never be replayed as a leaf.
+			parentHash := mt.hashNode(left.Hash, right.Hash)
 			parent := &MerkleNode{
 				Hash:   parentHash,
 				Left:   left,
@@ -104,6 +111,14 @@ func (mt *MerkleTree) Build(dataBlocks []string) {
 	}
 }
 
+// hashNode hex-decodes the left/right hashes, combines them via the
+// tree's Hasher, and returns the hex-encoded result.
+func (mt *MerkleTree) hashNode(leftHex, rightHex string) string {
+	left, _ := hex.DecodeString(leftHex)
+	right, _ := hex.DecodeString(rightHex)
+	return hex.EncodeToString(mt.Hasher.HashNode(left, right))
+}
+
 // GetRootHash returns the root hash of the tree
 func (mt *MerkleTree) GetRootHash() string {
 	if mt.Root == nil {
@@ -171,9 +186,9 @@ func (mt *MerkleTree) VerifyLeaf(leafIndex int, data string, proof []MerkleProof
 	// Traverse proof path
 	for _, p := range proof {
 		if p.Position == "left" {
-			currentHash = mt.Hash(p.SiblingHash + currentHash)
+			currentHash = mt.hashNode(p.SiblingHash, currentHash)
 		} else {
-			currentHash = mt.Hash(currentHash + p.SiblingHash)
+			currentHash = mt.hashNode(currentHash, p.SiblingHash)
 		}
 	}
 
@@ -199,7 +214,7 @@ func (mt *MerkleTree) getNodeHeight(node *MerkleNode) int {
 // Display returns a string representation of the tree
 func (mt *MerkleTree) Display() string {
 	result := []string{
-		fmt.Sprintf("Merkle Tree (hash=%s)", mt.HashFunc),
+		fmt.Sprintf("Merkle Tree (hash=%s)", mt.HasherName),
 		fmt.Sprintf("Number of leaves: %d", len(mt.Leaves)),
 		fmt.Sprintf("Height: %d", mt.GetHeight()),
 		fmt.Sprintf("Root hash: %s", mt.GetRootHash()),
@@ -276,3 +291,291 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// SMTDepth is the fixed depth of the sparse Merkle tree, one level per bit
+// of a SHA-256 key hash.
+const SMTDepth = 256
+
+// smtZeroHashes holds the precomputed hash of an empty subtree at each
+// level, so an absent key can be proven without materializing any nodes.
+var smtZeroHashes = buildSMTZeroHashes()
+
+func buildSMTZeroHashes() [SMTDepth + 1]string {
+	var zeroes [SMTDepth + 1]string
+	zeroes[0] = fmt.Sprintf("%x", sha256.Sum256(nil))
+	for level := 1; level <= SMTDepth; level++ {
+		combined := zeroes[level-1] + zeroes[level-1]
+		hash := sha256.Sum256([]byte(combined))
+		zeroes[level] = fmt.Sprintf("%x", hash)
+	}
+	return zeroes
+}
+
+// smtNode is an internal or leaf node of a SparseMerkleTree. Only occupied
+// subtrees are materialized; everything else is implied by smtZeroHashes.
+type smtNode struct {
+	Hash        string
+	Left, Right *smtNode
+	IsLeaf      bool
+	Key         []byte
+	Value       []byte
+}
+
+// SMTProof is a membership or non-membership proof for a single key.
+type SMTProof struct {
+	Siblings []string // sibling hash at each level, root to leaf
+	Key      []byte
+	// Membership indicates whether the target slot held Key/Value.
+	Membership bool
+	Value      []byte
+	// ActualKey/ActualValue describe the occupant of the leaf slot when the
+	// proof target is absent but a different key collides on the path
+	// (non-membership against a leaf rather than an empty subtree).
+	ActualKey   []byte
+	ActualValue []byte
+}
+
+// SparseMerkleTree is a fixed-depth (SMTDepth) authenticated key-value
+// store: every possible key has a position in the tree, so absence of a
+// key can be proven as cheaply as its presence.
+type SparseMerkleTree struct {
+	Root *smtNode
+}
+
+// NewSparseMerkleTree creates an empty sparse Merkle tree.
+func NewSparseMerkleTree() *SparseMerkleTree {
+	return &SparseMerkleTree{}
+}
+
+// smtKeyPath returns the bit path (root to leaf) derived from hash(key).
+func smtKeyPath(key []byte) []bool {
+	digest := sha256.Sum256(key)
+	path := make([]bool, SMTDepth)
+	for i := 0; i < SMTDepth; i++ {
+		byteIdx := i / 8
+		bitIdx := 7 - uint(i%8)
+		path[i] = (digest[byteIdx]>>bitIdx)&1 == 1
+	}
+	return path
+}
+
+func smtLeafHash(key, value []byte) string {
+	hash := sha256.Sum256(append(append([]byte{}, key...), value...))
+	return fmt.Sprintf("%x", hash)
+}
+
+// GetRootHash returns the current root hash, or the depth-0 zero hash for
+// an empty tree.
+func (smt *SparseMerkleTree) GetRootHash() string {
+	if smt.Root == nil {
+		return smtZeroHashes[SMTDepth]
+	}
+	return smt.Root.Hash
+}
+
+// Update inserts or overwrites the value stored at key.
+func (smt *SparseMerkleTree) Update(key, value []byte) {
+	path := smtKeyPath(key)
+	smt.Root = smtInsert(smt.Root, path, 0, key, value)
+}
+
+// Delete removes key from the tree, if present.
+func (smt *SparseMerkleTree) Delete(key []byte) {
+	// smtRemove drops whichever leaf ends key's path, which may hold a
+	// different key, so an absent key must leave the tree untouched.
+	if _, ok := smt.Get(key); !ok {
+		return
+	}
+	path := smtKeyPath(key)
+	smt.Root = smtRemove(smt.Root, path, 0)
+}
+
+// Get returns the value stored at key, if any.
+func (smt *SparseMerkleTree) Get(key []byte) ([]byte, bool) {
+	node := smt.Root
+	path := smtKeyPath(key)
+	for level := 0; node != nil && !node.IsLeaf; level++ {
+		if path[level] {
+			node = node.Right
+		} else {
+			node = node.Left
+		}
+	}
+	if node == nil || !bytesEqual(node.Key, key) {
+		return nil, false
+	}
+	return node.Value, true
+}
+
+// smtInsert descends the tree along path, creating internal nodes as
+// needed and recomputing hashes bottom-up.
+func smtInsert(node *smtNode, path []bool, level int, key, value []byte) *smtNode {
+	if level == SMTDepth {
+		return &smtNode{Hash: smtLeafHash(key, value), IsLeaf: true, Key: key, Value: value}
+	}
+
+	if node == nil {
+		// Empty subtree: insert a single leaf, deferring the full
+		// internal-node chain until a second key collides on this path.
+		return &smtNode{Hash: smtLeafHash(key, value), IsLeaf: true, Key: key, Value: value}
+	}
+
+	if node.IsLeaf {
+		if bytesEqual(node.Key, key) {
+			return &smtNode{Hash: smtLeafHash(key, value), IsLeaf: true, Key: key, Value: value}
+		}
+		// Push the existing leaf down until the two keys' paths diverge.
+		return smtInsert(smtSplitLeaf(node, path, level), path, level, key, value)
+	}
+
+	if path[level] {
+		node.Right = smtInsert(node.Right, path, level+1, key, value)
+	} else {
+		node.Left = smtInsert(node.Left, path, level+1, key, value)
+	}
+	node.Hash = smtNodeHash(node.Left, node.Right, level+1)
+	return node
+}
+
+// smtSplitLeaf replaces a leaf with an internal node containing that leaf
+// pushed one level deeper, so a colliding insert can continue descending.
+func smtSplitLeaf(leaf *smtNode, path []bool, level int) *smtNode {
+	existingPath := smtKeyPath(leaf.Key)
+	internal := &smtNode{}
+	if existingPath[level] {
+		internal.Right = leaf
+	} else {
+		internal.Left = leaf
+	}
+	internal.Hash = smtNodeHash(internal.Left, internal.Right, level+1)
+	return internal
+}
+
+// smtNodeHash computes the hash of an internal node, substituting the
+// zero-hash for the given level for either missing child.
+func smtNodeHash(left, right *smtNode, childLevel int) string {
+	leftHash := smtZeroHashes[SMTDepth-childLevel]
+	rightHash := smtZeroHashes[SMTDepth-childLevel]
+	if left != nil {
+		leftHash = left.Hash
+	}
+	if right != nil {
+		rightHash = right.Hash
+	}
+	hash := sha256.Sum256([]byte(leftHash + rightHash))
+	return fmt.Sprintf("%x", hash)
+}
+
+// smtRemove descends path deleting the leaf at the end, collapsing any
+// internal node left with no children.
+func smtRemove(node *smtNode, path []bool, level int) *smtNode {
+	if node == nil {
+		return nil
+	}
+	if node.IsLeaf {
+		return nil
+	}
+
+	if path[level] {
+		node.Right = smtRemove(node.Right, path, level+1)
+	} else {
+		node.Left = smtRemove(node.Left, path, level+1)
+	}
+
+	if node.Left == nil && node.Right == nil {
+		return nil
+	}
+	node.Hash = smtNodeHash(node.Left, node.Right, level+1)
+	return node
+}
+
+// GenerateProof builds a membership or non-membership proof for key.
+func (smt *SparseMerkleTree) GenerateProof(key []byte) *SMTProof {
+	path := smtKeyPath(key)
+	proof := &SMTProof{Key: key}
+
+	node := smt.Root
+	siblings := make([]string, 0, SMTDepth)
+	for level := 0; level < SMTDepth; level++ {
+		if node == nil {
+			// Remainder of the path is an empty subtree; every sibling
+			// from here to the leaf is a zero-hash.
+			for l := level; l < SMTDepth; l++ {
+				siblings = append(siblings, smtZeroHashes[SMTDepth-l-1])
+			}
+			break
+		}
+		if node.IsLeaf {
+			break
+		}
+		if path[level] {
+			siblings = append(siblings, siblingHash(node.Left, level+1))
+			node = node.Right
+		} else {
+			siblings = append(siblings, siblingHash(node.Right, level+1))
+			node = node.Left
+		}
+	}
+
+	proof.Siblings = siblings
+	if node != nil && node.IsLeaf && bytesEqual(node.Key, key) {
+		proof.Membership = true
+		proof.Value = node.Value
+	} else if node != nil && node.IsLeaf {
+		// Non-membership: a different key occupies this leaf slot.
+		proof.ActualKey = node.Key
+		proof.ActualValue = node.Value
+	}
+	return proof
+}
+
+func siblingHash(node *smtNode, childLevel int) string {
+	if node == nil {
+		return smtZeroHashes[SMTDepth-childLevel]
+	}
+	return node.Hash
+}
+
+// VerifySMTProof reconstructs the root implied by proof and compares it
+// against root. It handles both membership and non-membership proofs.
+func VerifySMTProof(root string, proof *SMTProof) bool {
+	path := smtKeyPath(proof.Key)
+
+	var current string
+	switch {
+	case proof.Membership:
+		current = smtLeafHash(proof.Key, proof.Value)
+	case proof.ActualKey != nil:
+		current = smtLeafHash(proof.ActualKey, proof.ActualValue)
+	default:
+		current = smtZeroHashes[SMTDepth-len(proof.Siblings)]
+	}
+
+	for level := len(proof.Siblings) - 1; level >= 0; level-- {
+		sibling := proof.Siblings[level]
+		if path[level] {
+			current = hashPair(sibling, current)
+		} else {
+			current = hashPair(current, sibling)
+		}
+	}
+
+	return current == root
+}
+
+func hashPair(left, right string) string {
+	hash := sha256.Sum256([]byte(left + right))
+	return fmt.Sprintf("%x", hash)
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}